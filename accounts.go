@@ -2,7 +2,14 @@ package neo
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type Account struct {
@@ -18,9 +25,186 @@ type Account struct {
 }
 
 type Balance struct {
-	Amount   string `json:"amount"`
-	Currency string `json:"currency"`
-	Type     string `json:"type"`
+	Amount        Amount      `json:"amount"`
+	Currency      Currency    `json:"currency"`
+	Type          BalanceType `json:"type"`
+	ReferenceDate *Date       `json:"referenceDate,omitempty"`      // The date the balance refers to, if supplied.
+	LastChanged   *time.Time  `json:"lastChangeDateTime,omitempty"` // When the balance last changed, if supplied.
+}
+
+func (b *Balance) UnmarshalJSON(data []byte) error {
+	type balance Balance
+	aux := &struct {
+		*balance
+		LastChanged string `json:"lastChangeDateTime"`
+	}{balance: (*balance)(b)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return fmt.Errorf("unable to unmarshal balance: %w", err)
+	}
+	b.LastChanged = nil
+	if t, ok := parseTimestamp(aux.LastChanged); ok {
+		b.LastChanged = &t
+	}
+	return nil
+}
+
+// BalanceType is the kind of balance, named after the Berlin Group balance types.
+type BalanceType string
+
+const (
+	BalanceClosingBooked    BalanceType = "closingBooked"
+	BalanceOpeningBooked    BalanceType = "openingBooked"
+	BalanceInterimBooked    BalanceType = "interimBooked"
+	BalanceClosingAvailable BalanceType = "closingAvailable"
+	BalanceOpeningAvailable BalanceType = "openingAvailable"
+	BalanceInterimAvailable BalanceType = "interimAvailable"
+	BalanceForwardAvailable BalanceType = "forwardAvailable"
+	BalanceExpected         BalanceType = "expected"
+	BalanceNonInvoiced      BalanceType = "nonInvoiced"
+)
+
+// balanceTypes maps the normalized Berlin Group names & ISO 20022 codes to the balance types.
+var balanceTypes = map[string]BalanceType{
+	"closingbooked":    BalanceClosingBooked,
+	"clbd":             BalanceClosingBooked,
+	"openingbooked":    BalanceOpeningBooked,
+	"opbd":             BalanceOpeningBooked,
+	"interimbooked":    BalanceInterimBooked,
+	"itbd":             BalanceInterimBooked,
+	"closingavailable": BalanceClosingAvailable,
+	"clav":             BalanceClosingAvailable,
+	"openingavailable": BalanceOpeningAvailable,
+	"opav":             BalanceOpeningAvailable,
+	"interimavailable": BalanceInterimAvailable,
+	"itav":             BalanceInterimAvailable,
+	"forwardavailable": BalanceForwardAvailable,
+	"fwav":             BalanceForwardAvailable,
+	"expected":         BalanceExpected,
+	"xpcd":             BalanceExpected,
+	"noninvoiced":      BalanceNonInvoiced,
+}
+
+// ParseBalanceType maps the balance type reported by a bank to a BalanceType. Berlin Group names
+// are matched regardless of case & separators (e.g. "CLOSING_BOOKED"), as are the ISO 20022 codes (e.g. "CLBD").
+// Unknown balance types are returned as-is.
+func ParseBalanceType(s string) BalanceType {
+	key := strings.ToLower(strings.NewReplacer("_", "", "-", "", " ", "").Replace(s))
+	if t, ok := balanceTypes[key]; ok {
+		return t
+	}
+	return BalanceType(s)
+}
+
+func (t *BalanceType) UnmarshalText(text []byte) error {
+	*t = ParseBalanceType(string(text))
+	return nil
+}
+
+// IsBooked reports whether the balance only includes booked transactions.
+func (t BalanceType) IsBooked() bool {
+	return t == BalanceClosingBooked || t == BalanceOpeningBooked || t == BalanceInterimBooked
+}
+
+// IsAvailable reports whether the balance represents the funds available to the end-user.
+func (t BalanceType) IsAvailable() bool {
+	switch t {
+	case BalanceClosingAvailable, BalanceOpeningAvailable, BalanceInterimAvailable, BalanceForwardAvailable:
+		return true
+	}
+	return false
+}
+
+// Balance returns the first balance matching the given types, in order of preference.
+func (acc *Account) Balance(types ...BalanceType) *Balance {
+	for _, t := range types {
+		for _, b := range acc.Balances {
+			if b != nil && b.Type == t {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// Available returns the balance available to the end-user. If the bank doesn't report one,
+// it falls back to a balance without a type, and then to the booked balance.
+func (acc *Account) Available() *Balance {
+	if b := acc.Balance(
+		BalanceInterimAvailable,
+		BalanceClosingAvailable,
+		BalanceExpected,
+		BalanceForwardAvailable,
+		BalanceOpeningAvailable,
+		"",
+	); b != nil {
+		return b
+	}
+	return acc.Booked()
+}
+
+// Booked returns the balance of the booked transactions. If the bank doesn't report one,
+// it falls back to a balance without a type.
+func (acc *Account) Booked() *Balance {
+	return acc.Balance(
+		BalanceInterimBooked,
+		BalanceClosingBooked,
+		BalanceOpeningBooked,
+		"",
+	)
+}
+
+// Fingerprint returns a stable identity of the account at the given bank, which, unlike the ID,
+// stays the same across sessions. It's an HMAC-SHA256 of the bank ID and the account number
+// under the given secret key, so it can be stored without revealing the latter as long as the key
+// is kept elsewhere: account numbers are few enough for a plain hash to be brute-forced.
+// NO, DK & FI IBANs are reduced to their BBANs, making them match accounts for which
+// the bank only reports the BBAN. It returns an empty string if the account has no account number.
+func (acc *Account) Fingerprint(key []byte, bankID string) string {
+	id := acc.canonicalNumber()
+	if id == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(bankID + "\x00" + id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalNumber returns the account number, prefixed by its kind.
+func (acc *Account) canonicalNumber() string {
+	if iban := NormalizeIBAN(acc.IBAN); iban != "" {
+		if bban, err := IBANToBBAN(iban); err == nil {
+			return "bban:" + bban
+		}
+		return "iban:" + iban
+	}
+	if bban := NormalizeBBAN(acc.BBAN); bban != "" {
+		return "bban:" + bban
+	}
+	if s := NormalizeSortCodeAccountNumber(acc.SortCodeAccountNumber); s != "" {
+		return "sort:" + s
+	}
+	return ""
+}
+
+// RemapAccountIDs matches the previously stored accounts of a bank against the accounts of a new session
+// by their account numbers, as Fingerprint does, returning the new account ID of each stored account ID.
+// Accounts without an account number, or which are no longer present, are left out.
+func RemapAccountIDs(stored, current []*Account) map[string]string {
+	ids := make(map[string]string, len(current))
+	for _, acc := range current {
+		if n := acc.canonicalNumber(); n != "" {
+			if _, ok := ids[n]; !ok {
+				ids[n] = acc.ID
+			}
+		}
+	}
+	m := make(map[string]string, len(stored))
+	for _, acc := range stored {
+		if id, ok := ids[acc.canonicalNumber()]; ok {
+			m[acc.ID] = id
+		}
+	}
+	return m
 }
 
 // Accounts returns a list of all accounts available in the given session.
@@ -58,3 +242,24 @@ func (a *API) AccountByID(
 	sca, err := a.do(req, http.StatusOK, acc)
 	return acc, sca, err
 }
+
+// Balances returns the current balances of the given account.
+func (a *API) Balances(
+	ctx context.Context,
+	sessionID string,
+	accountID string,
+	opts ...Optional,
+) ([]*Balance, *SCAHandler, error) {
+	if sessionID == "" {
+		return nil, nil, ErrInvalidSessionID
+	}
+	if accountID == "" {
+		return nil, nil, ErrInvalidAccountID
+	}
+	opts = append(opts, SessionID(sessionID))
+	uri := fmt.Sprintf("/ics/v3/accounts/%s/balances", accountID)
+	req := a.request(ctx, http.MethodGet, uri, nil, opts...)
+	bs := make([]*Balance, 0, 4)
+	sca, err := a.do(req, http.StatusOK, &bs)
+	return bs, sca, err
+}
@@ -2,9 +2,11 @@ package neo_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"testing"
 
 	"github.com/enfunc/neo"
 )
@@ -38,5 +40,73 @@ func checkSandboxAccounts(ctx context.Context, api *neo.API, s *neo.Session, opt
 	if !reflect.DeepEqual(fst, snd) {
 		return nil, fmt.Errorf("checkSandboxAccounts: %v != %v", fst, snd)
 	}
+
+	bs, sca, err := api.Balances(ctx, s.ID, fst.ID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if sca != nil {
+		waitForConsent(sca.SCA)
+		if _, err := sca.Retry(ctx, &bs); err != nil {
+			return nil, err
+		}
+	}
+	if len(bs) == 0 {
+		return nil, errors.New("checkSandboxAccounts: no balances")
+	}
 	return acc, nil
 }
+
+func TestAccountBalances(t *testing.T) {
+	acc := &neo.Account{}
+	if err := json.Unmarshal([]byte(`{"balances":[
+		{"amount":"90.00","currency":"NOK","type":"CLBD"},
+		{"amount":"100.00","currency":"NOK","type":"interimBooked","referenceDate":"2022-03-01"},
+		{"amount":"80.50","currency":"NOK","type":"INTERIM_AVAILABLE"}
+	]}`), acc); err != nil {
+		t.Fatal(err)
+	}
+	if b := acc.Available(); b == nil || b.Type != neo.BalanceInterimAvailable || b.Amount.String() != "80.50" {
+		t.Fatalf("TestAccountBalances: unexpected available balance %v", b)
+	}
+	if b := acc.Booked(); b == nil || b.Type != neo.BalanceInterimBooked || b.ReferenceDate == nil {
+		t.Fatalf("TestAccountBalances: unexpected booked balance %v", b)
+	}
+	if b := acc.Balance(neo.BalanceClosingBooked); b == nil || b.Amount.String() != "90.00" {
+		t.Fatalf("TestAccountBalances: unexpected closing balance %v", b)
+	}
+
+	acc.Balances = []*neo.Balance{{Amount: neo.MustParseAmount("5"), Currency: neo.CurrencyNOK}}
+	if acc.Available() != acc.Balances[0] || acc.Booked() != acc.Balances[0] {
+		t.Fatal("TestAccountBalances: an untyped balance should be used as a fallback")
+	}
+}
+
+func TestAccountFingerprint(t *testing.T) {
+	stored := []*neo.Account{
+		{ID: "old-1", IBAN: "NO93 8601 1117 947"},
+		{ID: "old-2", SortCodeAccountNumber: "60-16-13 31926819"},
+		{ID: "old-3"},
+	}
+	current := []*neo.Account{
+		{ID: "new-2", SortCodeAccountNumber: "60161331926819"},
+		{ID: "new-1", BBAN: "8601.11.17947"},
+	}
+	key := []byte("secret")
+	if fp := stored[0].Fingerprint(key, "dnb"); fp != current[1].Fingerprint(key, "dnb") || len(fp) != 64 {
+		t.Fatalf("TestAccountFingerprint: expected matching fingerprints, got %q", fp)
+	}
+	if stored[0].Fingerprint(key, "dnb") == stored[0].Fingerprint(key, "sbanken") {
+		t.Fatal("TestAccountFingerprint: the fingerprint should depend on the bank")
+	}
+	if stored[0].Fingerprint(key, "dnb") == stored[0].Fingerprint([]byte("other"), "dnb") {
+		t.Fatal("TestAccountFingerprint: the fingerprint should depend on the key")
+	}
+	if fp := stored[2].Fingerprint(key, "dnb"); fp != "" {
+		t.Fatalf("TestAccountFingerprint: expected no fingerprint, got %q", fp)
+	}
+	m := neo.RemapAccountIDs(stored, current)
+	if !reflect.DeepEqual(m, map[string]string{"old-1": "new-1", "old-2": "new-2"}) {
+		t.Fatalf("TestAccountFingerprint: unexpected mapping %v", m)
+	}
+}
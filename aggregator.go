@@ -0,0 +1,170 @@
+package neo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultAggregatorWorkers is the default number of concurrent requests made by an Aggregator.
+const DefaultAggregatorWorkers = 4
+
+// Aggregator fetches the accounts of several sessions, e.g. all the banks an end-user
+// has connected, concurrently. A failure or an SCA in one session doesn't affect the others.
+type Aggregator struct {
+	API      *API
+	Workers  int        // The maximum number of concurrent requests. Defaults to DefaultAggregatorWorkers.
+	Balances bool       // Whether to fetch the balances of each account.
+	Txs      bool       // Whether to fetch the transactions of each account.
+	TxOpts   []Optional // Additional options for fetching the transactions.
+}
+
+// Aggregation is the combined view of the aggregated sessions.
+type Aggregation struct {
+	Sessions []*AggregatedSession // In the order the sessions were requested.
+}
+
+type AggregatedSession struct {
+	SessionID string
+	Accounts  []*AggregatedAccount
+	SCA       *SCAHandler // Set if the end-user has to consent before the accounts can be retrieved.
+	Err       error       // Set if the accounts couldn't be retrieved.
+}
+
+type AggregatedAccount struct {
+	*Account
+	Balances []*Balance
+	Txs      []*Tx
+	SCA      *SCAHandler // Set if the end-user has to consent before the balances or transactions can be retrieved.
+	Err      error       // Set if the balances or transactions couldn't be retrieved.
+}
+
+// NewAggregator creates an aggregator on top of the given API.
+func NewAggregator(api *API) *Aggregator {
+	return &Aggregator{
+		API:     api,
+		Workers: DefaultAggregatorWorkers,
+	}
+}
+
+// Aggregate fetches the accounts of the given sessions, along with their balances and transactions if enabled.
+// The options are passed along to every request, e.g. PsuID.
+func (g *Aggregator) Aggregate(ctx context.Context, sessionIDs []string, opts ...Optional) *Aggregation {
+	workers := g.Workers
+	if workers <= 0 {
+		workers = DefaultAggregatorWorkers
+	}
+	p := &pool{sem: make(chan struct{}, workers)}
+	agg := &Aggregation{Sessions: make([]*AggregatedSession, len(sessionIDs))}
+	for i, id := range sessionIDs {
+		s := &AggregatedSession{SessionID: id}
+		agg.Sessions[i] = s
+		p.Go(ctx, func(ctx context.Context) {
+			acc, sca, err := g.API.Accounts(ctx, s.SessionID, opts...)
+			if err != nil || sca != nil {
+				s.SCA, s.Err = sca, err
+				return
+			}
+			s.Accounts = make([]*AggregatedAccount, len(acc))
+			for j, a := range acc {
+				s.Accounts[j] = &AggregatedAccount{Account: a}
+				g.details(ctx, p, s.SessionID, s.Accounts[j], opts)
+			}
+		}, func(err error) {
+			s.Err = err
+		})
+	}
+	p.Wait()
+	return agg
+}
+
+// details fetches the balances & transactions of the account, if enabled.
+func (g *Aggregator) details(ctx context.Context, p *pool, sessionID string, acc *AggregatedAccount, opts []Optional) {
+	if !g.Balances && !g.Txs {
+		return
+	}
+	p.Go(ctx, func(ctx context.Context) {
+		if g.Balances {
+			bs, sca, err := g.API.Balances(ctx, sessionID, acc.ID, opts...)
+			if err != nil || sca != nil {
+				acc.SCA, acc.Err = sca, err
+				return
+			}
+			acc.Balances = bs
+		}
+		if g.Txs {
+			txOpts := append(append([]Optional{}, opts...), g.TxOpts...)
+			// Keep the transactions retrieved before a partial failure or an SCA.
+			acc.Txs, acc.SCA, acc.Err = g.API.Txs(ctx, sessionID, acc.ID, txOpts...)
+		}
+	}, func(err error) {
+		acc.Err = err
+	})
+}
+
+// Accounts returns all the accounts which were retrieved.
+func (g *Aggregation) Accounts() []*AggregatedAccount {
+	var acc []*AggregatedAccount
+	for _, s := range g.Sessions {
+		acc = append(acc, s.Accounts...)
+	}
+	return acc
+}
+
+// SCAs returns the handlers of all the SCAs the end-user has to complete.
+func (g *Aggregation) SCAs() []*SCAHandler {
+	var scas []*SCAHandler
+	for _, s := range g.Sessions {
+		if s.SCA != nil {
+			scas = append(scas, s.SCA)
+		}
+		for _, a := range s.Accounts {
+			if a.SCA != nil {
+				scas = append(scas, a.SCA)
+			}
+		}
+	}
+	return scas
+}
+
+// Err returns the first error encountered, if any.
+func (g *Aggregation) Err() error {
+	for _, s := range g.Sessions {
+		if s.Err != nil {
+			return fmt.Errorf("neo: session %s: %w", s.SessionID, s.Err)
+		}
+		for _, a := range s.Accounts {
+			if a.Err != nil {
+				return fmt.Errorf("neo: account %s: %w", a.ID, a.Err)
+			}
+		}
+	}
+	return nil
+}
+
+// pool runs functions concurrently, with at most cap(sem) of them running at once.
+type pool struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// Go runs fn once a worker is available, or calls canceled if the context is done first.
+func (p *pool) Go(ctx context.Context, fn func(ctx context.Context), canceled func(err error)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		select {
+		case <-ctx.Done():
+			canceled(ctx.Err())
+			return
+		case p.sem <- struct{}{}:
+		}
+		defer func() { <-p.sem }()
+		fn(ctx)
+	}()
+}
+
+// Wait blocks until all the functions have returned.
+func (p *pool) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,47 @@
+package neo_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/enfunc/neo"
+)
+
+func TestAggregator(t *testing.T) {
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		status, body := http.StatusOK, `[{"id":"acc-1"},{"id":"acc-2"}]`
+		switch {
+		case r.Header.Get(neo.HeaderSessionID) == "consent":
+			status, body = 510, `{"type":"CONSENT","errorCode":"1426","links":[{"href":"https://bank.no/consent","meta":{"id":"consent"}}]}`
+		case r.Header.Get(neo.HeaderSessionID) == "down":
+			status, body = http.StatusInternalServerError, `{}`
+		case strings.HasSuffix(r.URL.Path, "/balances"):
+			body = `[{"amount":"10.00","currency":"NOK","type":"interimAvailable"}]`
+		}
+		return response(status, body, nil), nil
+	})
+	api := fakeAPI(d)
+	g := neo.NewAggregator(api)
+	g.Balances = true
+	agg := g.Aggregate(context.TODO(), []string{"ok", "consent", "down"})
+
+	if len(agg.Sessions) != 3 {
+		t.Fatalf("expected 3 sessions, got %d", len(agg.Sessions))
+	}
+	if acc := agg.Accounts(); len(acc) != 2 {
+		t.Fatalf("expected 2 accounts, got %d", len(acc))
+	}
+	for _, a := range agg.Accounts() {
+		if a.Err != nil || len(a.Balances) != 1 {
+			t.Fatalf("%s: expected a balance, got %v (%v)", a.ID, a.Balances, a.Err)
+		}
+	}
+	if scas := agg.SCAs(); len(scas) != 1 || scas[0].URL != "https://bank.no/consent" {
+		t.Fatalf("expected the consent SCA, got %v", scas)
+	}
+	if agg.Sessions[2].Err == nil || agg.Err() == nil {
+		t.Fatal("expected the failing session to be reported")
+	}
+}
@@ -0,0 +1,262 @@
+package neo
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxAmountScale is the maximum number of decimal digits an Amount can hold.
+const maxAmountScale = 18
+
+// Amount is an exact decimal amount of money, such as 12.50.
+// The zero value is a valid amount of 0.
+type Amount struct {
+	value int64 // The unscaled value, e.g. 1250 for 12.50.
+	scale int   // The number of decimal digits, e.g. 2 for 12.50.
+}
+
+// NewAmount returns the amount value × 10^-scale, e.g. NewAmount(1250, 2) is 12.50.
+func NewAmount(value int64, scale int) Amount {
+	if scale < 0 || scale > maxAmountScale {
+		panic(fmt.Sprintf("neo: invalid amount scale %d", scale))
+	}
+	return Amount{value: value, scale: scale}
+}
+
+// AmountFromMinor returns the amount for the given number of minor units of the currency,
+// e.g. AmountFromMinor(1250, "NOK") is 12.50.
+func AmountFromMinor(minor int64, currency Currency) Amount {
+	return NewAmount(minor, currency.MinorUnits())
+}
+
+// ParseAmount parses a decimal amount in the format used by the Neonomics platform,
+// i.e. an optional sign, digits and an optional point followed by digits: "-1234.50".
+// Thousands separators, decimal commas and exponents are rejected.
+func ParseAmount(s string) (Amount, error) {
+	num := s
+	neg := false
+	if num != "" && (num[0] == '-' || num[0] == '+') {
+		neg = num[0] == '-'
+		num = num[1:]
+	}
+	whole, frac, dot := strings.Cut(num, ".")
+	if whole == "" || (dot && frac == "") || !isDigits(whole) || !isDigits(frac) || len(frac) > maxAmountScale {
+		return Amount{}, fmt.Errorf("neo: %w: %q", ErrInvalidAmount, s)
+	}
+	v, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("neo: %w: %q is out of range", ErrInvalidAmount, s)
+	}
+	if neg {
+		v = -v
+	}
+	return Amount{value: v, scale: len(frac)}, nil
+}
+
+// MustParseAmount is like ParseAmount, but panics if the amount is invalid.
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Scale returns the number of decimal digits the amount is represented with.
+func (a Amount) Scale() int {
+	return a.scale
+}
+
+// Decimals returns the number of significant decimal digits, e.g. 1 for 12.50.
+func (a Amount) Decimals() int {
+	v, d := a.value, a.scale
+	for d > 0 && v%10 == 0 {
+		v /= 10
+		d--
+	}
+	return d
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount.
+func (a Amount) Sign() int {
+	switch {
+	case a.value < 0:
+		return -1
+	case a.value > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero reports whether the amount equals 0.
+func (a Amount) IsZero() bool {
+	return a.value == 0
+}
+
+// Cmp compares the amounts, returning -1 if a < b, 0 if a == b and +1 if a > b.
+// The scale is irrelevant, i.e. 12.5 equals 12.50.
+func (a Amount) Cmp(b Amount) int {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.unscaled(scale).Cmp(b.unscaled(scale))
+}
+
+// Equal reports whether the amounts are equal, regardless of their scale.
+func (a Amount) Equal(b Amount) bool {
+	return a.Cmp(b) == 0
+}
+
+// Add returns a + b. It panics if the result doesn't fit into an Amount.
+func (a Amount) Add(b Amount) Amount {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return amountOf(new(big.Int).Add(a.unscaled(scale), b.unscaled(scale)), scale)
+}
+
+// Sub returns a - b. It panics if the result doesn't fit into an Amount.
+func (a Amount) Sub(b Amount) Amount {
+	return a.Add(b.Neg())
+}
+
+// Mul returns a × n. It panics if the result doesn't fit into an Amount.
+func (a Amount) Mul(n int64) Amount {
+	return amountOf(new(big.Int).Mul(a.unscaled(a.scale), big.NewInt(n)), a.scale)
+}
+
+// Neg returns -a.
+func (a Amount) Neg() Amount {
+	return amountOf(new(big.Int).Neg(a.unscaled(a.scale)), a.scale)
+}
+
+// Abs returns |a|.
+func (a Amount) Abs() Amount {
+	if a.value < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// Round rounds the amount to the given number of decimal digits, rounding half away from zero.
+func (a Amount) Round(scale int) Amount {
+	if scale < 0 || scale > maxAmountScale {
+		panic(fmt.Sprintf("neo: invalid amount scale %d", scale))
+	}
+	if scale >= a.scale {
+		return amountOf(a.unscaled(scale), scale)
+	}
+	d := pow10(a.scale - scale)
+	q, r := new(big.Int).QuoRem(big.NewInt(a.value), d, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(d) >= 0 {
+		q.Add(q, big.NewInt(int64(a.Sign())))
+	}
+	return amountOf(q, scale)
+}
+
+// Minor returns the amount in minor units of the given currency, e.g. 1250 for 12.50 NOK.
+// It fails if the amount has more decimals than the currency allows.
+func (a Amount) Minor(currency Currency) (int64, error) {
+	u := currency.MinorUnits()
+	if a.Decimals() > u {
+		return 0, fmt.Errorf("neo: %w: %s has more than %d decimals", ErrInvalidAmount, a, u)
+	}
+	if u <= a.scale {
+		return a.Round(u).value, nil
+	}
+	v := a.unscaled(u)
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("neo: %w: %s is out of range in minor units of %s", ErrInvalidAmount, a, currency)
+	}
+	return v.Int64(), nil
+}
+
+// Format formats the amount with the number of decimals used by the given currency, e.g. "12.50" for NOK.
+// Amounts with fewer decimals are padded with zeros, so that any amount can be formatted.
+func (a Amount) Format(currency Currency) string {
+	u := currency.MinorUnits()
+	if u <= a.scale {
+		return a.Round(u).String()
+	}
+	s := a.String()
+	if a.scale == 0 {
+		s += "."
+	}
+	return s + strings.Repeat("0", u-a.scale)
+}
+
+// String formats the amount using its own scale, e.g. "-12.50".
+func (a Amount) String() string {
+	digits := new(big.Int).Abs(big.NewInt(a.value)).String()
+	if a.scale > 0 {
+		if len(digits) <= a.scale {
+			digits = strings.Repeat("0", a.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-a.scale] + "." + digits[len(digits)-a.scale:]
+	}
+	if a.value < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalText(text []byte) error {
+	p, err := ParseAmount(string(text))
+	if err != nil {
+		return err
+	}
+	*a = p
+	return nil
+}
+
+// MarshalJSON encodes the amount as a JSON string, as expected by the Neonomics platform.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(a.String())), nil
+}
+
+// UnmarshalJSON decodes the amount from either a JSON string or a JSON number.
+// An empty string is no amount, so it's rejected rather than taken for zero.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*a = Amount{}
+		return nil
+	}
+	return a.UnmarshalText(bytes.Trim(data, `"`))
+}
+
+func (a Amount) unscaled(scale int) *big.Int {
+	v := big.NewInt(a.value)
+	if scale > a.scale {
+		v.Mul(v, pow10(scale-a.scale))
+	}
+	return v
+}
+
+func amountOf(v *big.Int, scale int) Amount {
+	if !v.IsInt64() {
+		panic(fmt.Sprintf("neo: amount %se-%d out of range", v, scale))
+	}
+	return Amount{value: v.Int64(), scale: scale}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,117 @@
+package neo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/enfunc/neo"
+)
+
+func TestParseAmount(t *testing.T) {
+	for s, want := range map[string]string{
+		"12.50":  "12.50",
+		"-0.05":  "-0.05",
+		"+1":     "1",
+		"007.10": "7.10",
+	} {
+		a, err := neo.ParseAmount(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.String() != want {
+			t.Fatalf("TestParseAmount: %s != %s", a, want)
+		}
+	}
+	for _, s := range []string{"", "12,5", "1 000", "1e3", ".5", "12.", "-", "99999999999999999999"} {
+		if _, err := neo.ParseAmount(s); !errors.Is(err, neo.ErrInvalidAmount) {
+			t.Fatalf("TestParseAmount: %q should be invalid", s)
+		}
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a := neo.MustParseAmount("0.1")
+	b := neo.MustParseAmount("0.20")
+	if got := a.Add(b).String(); got != "0.30" {
+		t.Fatalf("TestAmountArithmetic: 0.1 + 0.20 = %s", got)
+	}
+	if got := a.Sub(b).String(); got != "-0.10" {
+		t.Fatalf("TestAmountArithmetic: 0.1 - 0.20 = %s", got)
+	}
+	if got := b.Mul(3).String(); got != "0.60" {
+		t.Fatalf("TestAmountArithmetic: 0.20 * 3 = %s", got)
+	}
+	if !neo.MustParseAmount("12.5").Equal(neo.MustParseAmount("12.500")) {
+		t.Fatal("TestAmountArithmetic: 12.5 != 12.500")
+	}
+	if a.Cmp(b) != -1 || b.Cmp(a) != 1 {
+		t.Fatal("TestAmountArithmetic: invalid comparison")
+	}
+	for s, want := range map[string]string{
+		"1.005":  "1.01",
+		"-1.005": "-1.01",
+		"1.004":  "1.00",
+		"2":      "2.00",
+	} {
+		if got := neo.MustParseAmount(s).Round(2).String(); got != want {
+			t.Fatalf("TestAmountArithmetic: round(%s) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestAmountMinorUnits(t *testing.T) {
+	if got := neo.AmountFromMinor(1250, "NOK").String(); got != "12.50" {
+		t.Fatalf("TestAmountMinorUnits: %s != 12.50", got)
+	}
+	if got := neo.AmountFromMinor(1250, "JPY").String(); got != "1250" {
+		t.Fatalf("TestAmountMinorUnits: %s != 1250", got)
+	}
+	m, err := neo.MustParseAmount("1.5").Minor("KWD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != 1500 {
+		t.Fatalf("TestAmountMinorUnits: %d != 1500", m)
+	}
+	if _, err := neo.MustParseAmount("1.505").Minor("EUR"); !errors.Is(err, neo.ErrInvalidAmount) {
+		t.Fatal("TestAmountMinorUnits: 1.505 EUR should be invalid")
+	}
+	for a, want := range map[string]string{
+		"3":                    "3.00",
+		"-0.5":                 "-0.50",
+		"1.005":                "1.01",
+		"922337203685477580":   "922337203685477580.00", // Too large to be scaled to cents.
+		"-92233720368547758.0": "-92233720368547758.00",
+	} {
+		if got := neo.MustParseAmount(a).Format("SEK"); got != want {
+			t.Fatalf("TestAmountMinorUnits: %s != %s", got, want)
+		}
+	}
+	if got := neo.MustParseAmount("12.5").Format("JPY"); got != "13" {
+		t.Fatalf("TestAmountMinorUnits: %s != 13", got)
+	}
+	if _, err := neo.MustParseAmount("922337203685477580").Minor("EUR"); !errors.Is(err, neo.ErrInvalidAmount) {
+		t.Fatal("TestAmountMinorUnits: 922337203685477580 EUR should be out of range in cents")
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	m := &neo.Money{}
+	if err := json.Unmarshal([]byte(`{"currency":"NOK","value":-1234.5}`), m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Value.String() != "-1234.5" {
+		t.Fatalf("TestAmountJSON: %s != -1234.5", m.Value)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"currency":"NOK","value":"-1234.5"}` {
+		t.Fatalf("TestAmountJSON: unexpected %s", b)
+	}
+	if err := json.Unmarshal([]byte(`{"currency":"NOK","value":""}`), m); !errors.Is(err, neo.ErrInvalidAmount) {
+		t.Fatalf("TestAmountJSON: an empty amount should be invalid, got %v", err)
+	}
+}
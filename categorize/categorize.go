@@ -0,0 +1,373 @@
+// Package categorize assigns categories to transactions using rules, e.g. groceries to the card
+// payments at REMA 1000. It ships a default set of Nordic merchant rules, which can be
+// extended & overridden per user.
+package categorize
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/enfunc/neo"
+)
+
+var ErrInvalidRule = errors.New("invalid rule")
+
+// Category is the category of a transaction.
+type Category string
+
+const (
+	Uncategorized Category = ""
+	Groceries     Category = "groceries"
+	Restaurants   Category = "restaurants"
+	Transport     Category = "transport"
+	Fuel          Category = "fuel"
+	Travel        Category = "travel"
+	Housing       Category = "housing"
+	Utilities     Category = "utilities"
+	Telecom       Category = "telecom"
+	Subscriptions Category = "subscriptions"
+	Shopping      Category = "shopping"
+	Health        Category = "health"
+	Insurance     Category = "insurance"
+	Cash          Category = "cash"
+	Fees          Category = "fees"
+	Taxes         Category = "taxes"
+	Income        Category = "income"
+	Transfers     Category = "transfers"
+)
+
+// Rule assigns its category to the transactions matching all of its criteria.
+// A rule must have at least one criterion.
+type Rule struct {
+	ID       string   `json:"id"`
+	Category Category `json:"category"`
+	// Among the matching rules, the one with the highest priority wins.
+	// On a tie, the rule that comes first wins.
+	Priority int `json:"priority"`
+
+	// Matched case-insensitively against the name of the counterparty, or against
+	// the unstructured remittance info if the name is unknown, as is common for card payments.
+	Counterparty *Pattern `json:"counterparty,omitempty"`
+	// Matched case-insensitively against the unstructured remittance info or the structured reference.
+	Remittance *Pattern `json:"remittance,omitempty"`
+	// The inclusive range of the absolute amount. Either bound may be left out.
+	MinAmount *neo.Amount `json:"minAmount,omitempty"`
+	MaxAmount *neo.Amount `json:"maxAmount,omitempty"`
+	// The merchant category codes of card payments, or ranges of them, e.g. 5811-5814.
+	MCC []string `json:"mcc,omitempty"`
+	// The IBANs or BBANs of the counterparty.
+	Accounts []string `json:"accounts,omitempty"`
+	// Whether the rule only matches incoming or outgoing transactions.
+	CreditDebit neo.CreditDebit `json:"creditDebit,omitempty"`
+}
+
+// Pattern is a regular expression, encoded in JSON as a string.
+type Pattern struct {
+	*regexp.Regexp
+	expr string
+}
+
+// NewPattern compiles the case-insensitive pattern.
+func NewPattern(expr string) (*Pattern, error) {
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("categorize: %w: %v", ErrInvalidRule, err) //nolint:errorlint
+	}
+	return &Pattern{Regexp: re, expr: expr}, nil
+}
+
+// MustPattern is like NewPattern but panics if the pattern is invalid.
+func MustPattern(expr string) *Pattern {
+	p, err := NewPattern(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern as given.
+func (p *Pattern) String() string {
+	return p.expr
+}
+
+func (p *Pattern) MarshalText() ([]byte, error) {
+	return []byte(p.expr), nil
+}
+
+func (p *Pattern) UnmarshalText(text []byte) error {
+	c, err := NewPattern(string(text))
+	if err != nil {
+		return err
+	}
+	*p = *c
+	return nil
+}
+
+// Result is the category assigned to a transaction.
+type Result struct {
+	Category Category
+	Rule     *Rule // The winning rule, nil if no rule matched or the category was set by hand.
+	// Why the category was assigned, e.g.
+	// rule "no-groceries" (priority 20): counterparty "REMA 1000 GRÜNERLØKKA" matches "rema ?1000".
+	Explanation string
+	// All the matching rules, the winning one first.
+	Matches []*Rule
+}
+
+// Engine categorizes transactions. It's safe for concurrent use.
+type Engine struct {
+	rules     []*rule
+	overrides *Overrides
+}
+
+type rule struct {
+	*Rule
+	mcc  [][2]int
+	user bool // Whether the rule is one of the overrides.
+}
+
+// NewEngine creates an engine of the given rules, e.g. DefaultRules(), with the user's overrides, if any.
+// The rules of the overrides take precedence over the given rules, whatever their priority.
+func NewEngine(rules []*Rule, overrides *Overrides) (*Engine, error) {
+	e := &Engine{overrides: overrides}
+	disabled := make(map[string]bool)
+	if overrides != nil {
+		if err := e.add(overrides.Rules, true, disabled); err != nil {
+			return nil, err
+		}
+		for _, id := range overrides.Disabled {
+			disabled[id] = true
+		}
+	}
+	if err := e.add(rules, false, disabled); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(e.rules, func(i, j int) bool {
+		a, b := e.rules[i], e.rules[j]
+		if a.user != b.user {
+			return a.user
+		}
+		return a.Priority > b.Priority
+	})
+	return e, nil
+}
+
+func (e *Engine) add(rules []*Rule, user bool, disabled map[string]bool) error {
+	for _, r := range rules {
+		if r == nil || disabled[r.ID] {
+			continue
+		}
+		c, err := compile(r)
+		if err != nil {
+			return err
+		}
+		c.user = user
+		e.rules = append(e.rules, c)
+	}
+	return nil
+}
+
+func compile(r *Rule) (*rule, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("categorize: %w %q: %s", ErrInvalidRule, r.ID, reason)
+	}
+	if r.Category == Uncategorized {
+		return nil, invalid("no category")
+	}
+	if r.Counterparty == nil && r.Remittance == nil && r.MinAmount == nil && r.MaxAmount == nil &&
+		len(r.MCC) == 0 && len(r.Accounts) == 0 && r.CreditDebit == "" {
+		return nil, invalid("no criteria")
+	}
+	c := &rule{Rule: r}
+	for _, code := range r.MCC {
+		from, to, isRange := strings.Cut(code, "-")
+		if !isRange {
+			to = from
+		}
+		lo, err1 := strconv.Atoi(strings.TrimSpace(from))
+		hi, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || lo > hi {
+			return nil, invalid(fmt.Sprintf("MCC %q", code))
+		}
+		c.mcc = append(c.mcc, [2]int{lo, hi})
+	}
+	return c, nil
+}
+
+// Categorize returns the category of the transaction: the one set by hand in the overrides,
+// or the one of the matching rule of the highest priority.
+func (e *Engine) Categorize(tx *neo.Tx) *Result {
+	res := &Result{}
+	var reasons []string
+	for _, r := range e.rules {
+		why, ok := r.match(tx)
+		if !ok {
+			continue
+		}
+		res.Matches = append(res.Matches, r.Rule)
+		if res.Rule == nil {
+			res.Rule, res.Category, reasons = r.Rule, r.Category, why
+		}
+	}
+	if c, ok := e.overrides.category(tx); ok {
+		res.Rule, res.Category = nil, c
+		res.Explanation = fmt.Sprintf("set by hand to %q", c)
+		return res
+	}
+	if res.Rule != nil {
+		res.Explanation = fmt.Sprintf("rule %q (priority %d): %s", res.Rule.ID, res.Rule.Priority, strings.Join(reasons, ", "))
+	}
+	return res
+}
+
+// match returns whether the rule matches the transaction, and why.
+func (r *rule) match(tx *neo.Tx) ([]string, bool) { //nolint:cyclop
+	var why []string
+	signed := tx.SignedAmount()
+	if r.CreditDebit != "" {
+		if cd := creditDebit(signed); cd != r.CreditDebit {
+			return nil, false
+		}
+		why = append(why, map[neo.CreditDebit]string{neo.Credit: "incoming", neo.Debit: "outgoing"}[r.CreditDebit])
+	}
+	if r.Counterparty != nil {
+		name := counterpartyName(tx)
+		if !r.Counterparty.MatchString(name) {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("counterparty %q matches %q", name, r.Counterparty))
+	}
+	if r.Remittance != nil {
+		rem, ok := "", false
+		for _, s := range remittances(tx) {
+			if r.Remittance.MatchString(s) {
+				rem, ok = s, true
+				break
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("remittance %q matches %q", rem, r.Remittance))
+	}
+	if r.MinAmount != nil || r.MaxAmount != nil {
+		a := signed.Abs()
+		if (r.MinAmount != nil && a.Cmp(*r.MinAmount) < 0) || (r.MaxAmount != nil && a.Cmp(*r.MaxAmount) > 0) {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("amount %s within %s", a, r.amountRange()))
+	}
+	if len(r.mcc) > 0 {
+		code, err := strconv.Atoi(tx.MerchantCategoryCode)
+		if err != nil || !r.matchMCC(code) {
+			return nil, false
+		}
+		why = append(why, "MCC "+tx.MerchantCategoryCode)
+	}
+	if len(r.Accounts) > 0 {
+		account, ok := r.matchAccount(tx)
+		if !ok {
+			return nil, false
+		}
+		why = append(why, "counterparty account "+account)
+	}
+	return why, true
+}
+
+func (r *rule) amountRange() string {
+	bound := func(a *neo.Amount) string {
+		if a == nil {
+			return ""
+		}
+		return a.String()
+	}
+	return "[" + bound(r.MinAmount) + ", " + bound(r.MaxAmount) + "]"
+}
+
+func (r *rule) matchMCC(code int) bool {
+	for _, m := range r.mcc {
+		if code >= m[0] && code <= m[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rule) matchAccount(tx *neo.Tx) (string, bool) {
+	numbers := counterpartyAccounts(tx)
+	for _, want := range r.Accounts {
+		want = accountKey(want)
+		for _, n := range numbers {
+			if accountKey(n) == want {
+				return n, true
+			}
+		}
+	}
+	return "", false
+}
+
+// accountKey returns the BBAN of an IBAN, so that both forms of an account number match.
+func accountKey(number string) string {
+	n := neo.NormalizeBBAN(number)
+	if bban, err := neo.IBANToBBAN(n); err == nil {
+		return neo.NormalizeBBAN(bban)
+	}
+	return n
+}
+
+func creditDebit(signed neo.Amount) neo.CreditDebit {
+	if signed.Sign() < 0 {
+		return neo.Debit
+	}
+	return neo.Credit
+}
+
+// counterpartyName returns the name of the other party of the transaction,
+// or the unstructured remittance info if the name is unknown.
+func counterpartyName(tx *neo.Tx) string {
+	name := tx.CounterpartyName
+	if name == "" {
+		name = tx.CreditorName
+		if tx.SignedAmount().Sign() > 0 {
+			name = tx.DebtorName
+		}
+	}
+	if name == "" {
+		name = tx.RemittanceInfoUnstructured
+	}
+	return name
+}
+
+func counterpartyAccounts(tx *neo.Tx) []string {
+	var numbers []string
+	if tx.CounterpartyAccount != "" {
+		numbers = append(numbers, tx.CounterpartyAccount)
+	}
+	other := tx.CreditorAccount
+	if tx.SignedAmount().Sign() > 0 {
+		other = tx.DebtorAccount
+	}
+	if other != nil {
+		for _, n := range []string{other.IBAN, other.BBAN} {
+			if n != "" {
+				numbers = append(numbers, n)
+			}
+		}
+	}
+	return numbers
+}
+
+func remittances(tx *neo.Tx) []string {
+	var r []string
+	if tx.RemittanceInfoUnstructured != "" {
+		r = append(r, tx.RemittanceInfoUnstructured)
+	}
+	if s := tx.RemittanceInfoStructured; s != nil && s.Reference != "" {
+		r = append(r, s.Reference)
+	}
+	return r
+}
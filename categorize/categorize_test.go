@@ -0,0 +1,146 @@
+package categorize_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/enfunc/neo"
+	"github.com/enfunc/neo/categorize"
+)
+
+func tx(id, amount string, cd neo.CreditDebit, name string) *neo.Tx {
+	return &neo.Tx{
+		ID:                   id,
+		TransactionAmount:    &neo.Money{Currency: neo.CurrencyNOK, Value: neo.MustParseAmount(amount)},
+		CreditDebitIndicator: cd,
+		CounterpartyName:     name,
+	}
+}
+
+func amount(s string) *neo.Amount {
+	a := neo.MustParseAmount(s)
+	return &a
+}
+
+func TestDefaultRules(t *testing.T) {
+	e, err := categorize.NewEngine(categorize.DefaultRules(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := tx("card", "54.90", neo.Debit, "")
+	card.RemittanceInfoUnstructured = "*4321 02.03 NOK 54.90 REMA 1000 GRUNERLOKKA Kurs: 1.0000"
+	mccOnly := tx("mcc", "129.00", neo.Debit, "UNKNOWN AS")
+	mccOnly.MerchantCategoryCode = "5812"
+	salary := tx("salary", "32500.00", neo.Credit, "Arbeidsgiver AS")
+	salary.RemittanceInfoUnstructured = "Lonn mars"
+
+	for _, test := range []struct {
+		tx       *neo.Tx
+		category categorize.Category
+		rule     string
+	}{
+		{card, categorize.Groceries, "groceries"},
+		{tx("vipps", "89.00", neo.Debit, "Vipps*Rema 1000 Torggata"), categorize.Groceries, "groceries"},
+		{tx("p2p", "200.00", neo.Debit, "Vipps Kari Nordmann"), categorize.Transfers, "transfers-p2p"},
+		{mccOnly, categorize.Restaurants, "restaurants-mcc"},
+		{tx("fuel", "650.00", neo.Debit, "CIRCLE K MAJORSTUEN"), categorize.Fuel, "fuel"},
+		{tx("power", "1250.00", neo.Debit, "Ørsted Salg & Service"), categorize.Utilities, "utilities"},
+		{salary, categorize.Income, "income-salary"},
+		{tx("refund", "54.90", neo.Credit, "REMA 1000"), categorize.Uncategorized, ""},
+		{tx("unknown", "10.00", neo.Debit, "Ola Nordmann"), categorize.Uncategorized, ""},
+	} {
+		res := e.Categorize(test.tx)
+		if res.Category != test.category {
+			t.Fatalf("%s: expected %q, got %q (%s)", test.tx.ID, test.category, res.Category, res.Explanation)
+		}
+		if test.rule == "" {
+			if res.Rule != nil || res.Explanation != "" {
+				t.Fatalf("%s: expected no rule, got %+v", test.tx.ID, res)
+			}
+			continue
+		}
+		if res.Rule == nil || res.Rule.ID != test.rule {
+			t.Fatalf("%s: expected the rule %q, got %+v", test.tx.ID, test.rule, res.Rule)
+		}
+	}
+
+	res := e.Categorize(tx("vipps", "89.00", neo.Debit, "Vipps*Rema 1000 Torggata"))
+	if len(res.Matches) != 2 || res.Matches[1].ID != "transfers-p2p" {
+		t.Fatalf("expected the lower priority match, got %+v", res.Matches)
+	}
+	want := `rule "groceries" (priority 20): outgoing, counterparty "Vipps*Rema 1000 Torggata" matches "rema ?1000|`
+	if !strings.HasPrefix(res.Explanation, want) {
+		t.Fatalf("unexpected explanation %q", res.Explanation)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	rent := tx("rent", "12000.00", neo.Debit, "Utleier AS")
+	rent.CreditorAccount = &neo.AccountInfo{IBAN: "NO83 3000 1234 567"}
+	coffee := tx("coffee", "45.00", neo.Debit, "Circle K Majorstuen")
+	fuel := tx("fuel", "650.00", neo.Debit, "Circle K Majorstuen")
+	p2p := tx("p2p", "200.00", neo.Debit, "Vipps Kari Nordmann")
+
+	o := &categorize.Overrides{
+		Rules: []*categorize.Rule{
+			{ID: "rent", Category: categorize.Housing, Accounts: []string{"3000.12.34567"}, MinAmount: amount("5000")},
+			{ID: "coffee", Category: categorize.Restaurants, Counterparty: categorize.MustPattern("circle k"), MaxAmount: amount("100")},
+		},
+		Disabled: []string{"transfers-p2p"},
+	}
+	o.Set(p2p, categorize.Shopping)
+
+	// The overrides survive a round-trip through JSON.
+	var buf bytes.Buffer
+	if err := o.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := categorize.LoadOverrides(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := categorize.NewEngine(categorize.DefaultRules(), loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		tx       *neo.Tx
+		category categorize.Category
+	}{
+		{rent, categorize.Housing},
+		{coffee, categorize.Restaurants},
+		{fuel, categorize.Fuel},
+		{p2p, categorize.Shopping},
+	} {
+		if res := e.Categorize(test.tx); res.Category != test.category {
+			t.Fatalf("%s: expected %q, got %q (%s)", test.tx.ID, test.category, res.Category, res.Explanation)
+		}
+	}
+	if res := e.Categorize(rent); !strings.Contains(res.Explanation, "counterparty account NO83 3000 1234 567") {
+		t.Fatalf("unexpected explanation %q", res.Explanation)
+	}
+
+	// Reverting the category set by hand falls back to the rules, without the disabled one.
+	loaded.Set(p2p, categorize.Uncategorized)
+	if res := e.Categorize(p2p); res.Category != categorize.Uncategorized {
+		t.Fatalf("expected no category, got %q (%s)", res.Category, res.Explanation)
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	for _, r := range []*categorize.Rule{
+		{ID: "no-category", Counterparty: categorize.MustPattern("x")},
+		{ID: "no-criteria", Category: categorize.Fees},
+		{ID: "mcc", Category: categorize.Fees, MCC: []string{"5999-5000"}},
+	} {
+		if _, err := categorize.NewEngine([]*categorize.Rule{r}, nil); !errors.Is(err, categorize.ErrInvalidRule) {
+			t.Fatalf("%s: expected ErrInvalidRule, got %v", r.ID, err)
+		}
+	}
+	_, err := categorize.LoadOverrides(strings.NewReader(`{"rules": [{"id": "x", "category": "fees", "counterparty": "("}]}`))
+	if !errors.Is(err, categorize.ErrInvalidRule) {
+		t.Fatalf("expected ErrInvalidRule, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package categorize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/enfunc/neo"
+)
+
+// Overrides are the changes a user made to the categorization, persisted as JSON:
+//
+//	{
+//	  "rules": [{"id": "gym", "category": "health", "counterparty": "sats|elixia"}],
+//	  "disabled": ["transfers-p2p"],
+//	  "txs": {"tx-1": "housing"}
+//	}
+//
+// Set may be called while the overrides are used by an engine.
+type Overrides struct {
+	Rules    []*Rule  `json:"rules,omitempty"`    // Rules which take precedence over the rule set.
+	Disabled []string `json:"disabled,omitempty"` // The IDs of the rules of the rule set to ignore.
+	// The categories set by hand, keyed by the ID of the transaction, or "hash:" and its hash
+	// if the bank doesn't report IDs.
+	Txs map[string]Category `json:"txs,omitempty"`
+
+	mu sync.RWMutex
+}
+
+// LoadOverrides reads overrides in JSON.
+func LoadOverrides(r io.Reader) (*Overrides, error) {
+	o := &Overrides{}
+	if err := json.NewDecoder(r).Decode(o); err != nil {
+		return nil, fmt.Errorf("categorize: unable to decode the overrides: %w", err)
+	}
+	return o, nil
+}
+
+// Save writes the overrides in JSON.
+func (o *Overrides) Save(w io.Writer) error {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(o); err != nil {
+		return fmt.Errorf("categorize: unable to encode the overrides: %w", err)
+	}
+	return nil
+}
+
+// Set sets the category of the transaction by hand. Setting it to Uncategorized reverts to the rules.
+func (o *Overrides) Set(tx *neo.Tx, c Category) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if c == Uncategorized {
+		delete(o.Txs, txKey(tx))
+		return
+	}
+	if o.Txs == nil {
+		o.Txs = make(map[string]Category)
+	}
+	o.Txs[txKey(tx)] = c
+}
+
+func (o *Overrides) category(tx *neo.Tx) (Category, bool) {
+	if o == nil {
+		return Uncategorized, false
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	c, ok := o.Txs[txKey(tx)]
+	return c, ok
+}
+
+func txKey(tx *neo.Tx) string {
+	if tx.ID != "" {
+		return tx.ID
+	}
+	return "hash:" + tx.Hash()
+}
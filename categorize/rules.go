@@ -0,0 +1,116 @@
+package categorize
+
+import "github.com/enfunc/neo"
+
+// The priorities of the default rules. Merchant names are more telling than merchant category codes,
+// which are in turn more telling than the generic payment services.
+const (
+	PriorityMCC      = 10
+	PriorityTransfer = 15
+	PriorityMerchant = 20
+	PriorityText     = 30
+)
+
+// DefaultRules returns the default rules for Norway, Sweden, Denmark & Finland:
+// the common merchants, their merchant category codes, and a few telling remittance texts.
+func DefaultRules() []*Rule {
+	return []*Rule{
+		merchants("groceries", Groceries, `rema ?1000|\bkiwi\b|\bmeny\b|\bcoop\b|\bspar\b|\bjoker\b|bunnpris|`+
+			`\bica\b|willys|hemk.p|city gross|\blidl\b|\bnetto\b|f.tex|\bbilka\b|\birma\b|\bfakta\b|\blovbjerg\b|`+
+			`s-market|\bprisma\b|k-citymarket|k-supermarket|k-market|\balepa\b|oda\.com|\bmathem\b`),
+		mcc("groceries-mcc", Groceries, "5411", "5422", "5441", "5451", "5462", "5499"),
+
+		merchants("restaurants", Restaurants, `espresso house|starbucks|joe & the juice|waynes coffee|peppes|`+
+			`mcdonald|burger king|\bmax burgers\b|foodora|\bwolt\b|just eat|\bhesburger\b`),
+		mcc("restaurants-mcc", Restaurants, "5811-5814"),
+
+		merchants("transport", Transport, `\bruter\b|\bvy\b|\bnsb\b|\bsj\b|\bsl\b|sk.netrafiken|v.sttrafik|\bdsb\b|rejsekort|`+
+			`\bhsl\b|\bkolumbus\b|\bskyss\b|\batb\b|\buber\b|\bbolt\b|\btaxi\b|easypark|\bapcoa\b|onepark|`+
+			`autopass|fjellinjen|\bparkering\b`),
+		mcc("transport-mcc", Transport, "4111", "4112", "4121", "4131", "4784", "7523"),
+
+		merchants("fuel", Fuel, `circle ?k|\bshell\b|\besso\b|uno-?x|\byx\b|\bst1\b|\bpreem\b|\bokq8\b|\bneste\b|\bq8\b|\bingo\b`),
+		mcc("fuel-mcc", Fuel, "5541", "5542", "5552"),
+
+		merchants("travel", Travel, `\bsas\b|norwegian air|\bfinnair\b|wider.e|\bryanair\b|booking\.com|airbnb|`+
+			`\bhotels\.com\b|\bscandic\b|nordic choice|strawberry|\bthon hotel`),
+		mcc("travel-mcc", Travel, "3000-3999", "4411", "4511", "4722", "7011"),
+
+		merchants("utilities", Utilities, `fjordkraft|\btibber\b|hafslund|vattenfall|\bfortum\b|ørsted|\be\.on\b|ellevio|`+
+			`\bhelen\b|\blyse\b|elvia|ishavskraft|norgesenergi|\bgöteborg energi|\bvann og avløp\b`),
+		mcc("utilities-mcc", Utilities, "4900"),
+
+		merchants("telecom", Telecom, `telenor|\btelia\b|ice (norge|communication)|\btele2\b|talkmore|onecall|`+
+			`\belisa\b|\bdna oyj\b|yousee|\btdc\b|\bnorlys\b|\baltibox\b|\bcomhem\b|\bget as\b`),
+		mcc("telecom-mcc", Telecom, "4812", "4814", "4816", "4899"),
+
+		merchants("subscriptions", Subscriptions, `netflix|spotify|\bhbo\b|viaplay|disney ?plus|disney\+|apple\.com/bill|`+
+			`youtube premium|\bstrim\b|c more|storytel|\bbookbeat\b|\bnextory\b|\btv ?2 play\b`),
+
+		merchants("shopping", Shopping, `\bikea\b|\bh ?& ?m\b|clas ohlson|elkj.p|elgiganten|\bgigantti\b|\bpower\b|\bjula\b|`+
+			`biltema|\bxxl\b|komplett|\bzalando\b|amazon|\bcdon\b|\bkicks\b|\bbirger christensen\b|`+
+			`\bvinmonopolet\b|systembolaget|\balko\b`),
+		mcc("shopping-mcc", Shopping, "5200-5399", "5600-5699", "5700-5735", "5900-5911", "5921-5999"),
+
+		merchants("health", Health, `apotek|vitusapotek|\bboots\b|apoteket|hj.rtat|kronans|\bapteekki\b|`+
+			`\btannlege\b|tandl.kare|\blegevakt|\bvårdcentral`),
+		mcc("health-mcc", Health, "5912", "8011", "8021", "8031", "8041", "8042", "8043", "8049", "8062", "8099"),
+
+		merchants("insurance", Insurance, `gjensidige|\btryg\b|fremtind|storebrand|if skadeforsikring|if försäkring|`+
+			`\bfolksam\b|l.nsf.rs.kringar|trygg-hansa|\blocaltapiola\b|\bcodan\b|\btopdanmark\b|\bdnb forsikring\b`),
+		mcc("insurance-mcc", Insurance, "6300"),
+
+		{
+			ID:           "cash-atm",
+			Category:     Cash,
+			Priority:     PriorityText,
+			Counterparty: MustPattern(`minibank|\batm\b|kontantuttak|bankomat|\buttag\b|h.vning|\bnosto\b|otto\.`),
+			CreditDebit:  neo.Debit,
+		},
+		mcc("cash-mcc", Cash, "6010", "6011"),
+
+		{
+			ID:          "fees-bank",
+			Category:    Fees,
+			Priority:    PriorityText,
+			Remittance:  MustPattern(`gebyr|årsavgift|kortavgift|\bavgift\b|\bpalvelumaksu\b|overtrekksrente`),
+			CreditDebit: neo.Debit,
+		},
+		{
+			ID:           "taxes",
+			Category:     Taxes,
+			Priority:     PriorityText,
+			Counterparty: MustPattern(`skatteetaten|skatteverket|\bskat\b|skattestyrelsen|verohallinto`),
+		},
+		{
+			ID:          "income-salary",
+			Category:    Income,
+			Priority:    PriorityText,
+			Remittance:  MustPattern(`lønn|\blonn\b|\blön\b|\bløn\b|palkka|salary|\bferiepenger\b|semesterersättning`),
+			CreditDebit: neo.Credit,
+		},
+		{
+			ID:           "income-benefits",
+			Category:     Income,
+			Priority:     PriorityText,
+			Counterparty: MustPattern(`\bnav\b|f.rs.kringskassan|pensionsmyndigheten|udbetaling danmark|\bkela\b`),
+			CreditDebit:  neo.Credit,
+		},
+		{
+			ID:           "transfers-p2p",
+			Category:     Transfers,
+			Priority:     PriorityTransfer,
+			Counterparty: MustPattern(`\bvipps\b|\bswish\b|mobilepay`),
+		},
+	}
+}
+
+// merchants matches the payments to the merchants of the pattern.
+func merchants(id string, c Category, expr string) *Rule {
+	return &Rule{ID: id, Category: c, Priority: PriorityMerchant, Counterparty: MustPattern(expr), CreditDebit: neo.Debit}
+}
+
+// mcc matches the card payments of the merchant category codes.
+func mcc(id string, c Category, codes ...string) *Rule {
+	return &Rule{ID: id, Category: c, Priority: PriorityMCC, MCC: codes, CreditDebit: neo.Debit}
+}
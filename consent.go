@@ -3,6 +3,8 @@ package neo
 import (
 	"context"
 	"net/http"
+	"sort"
+	"time"
 )
 
 type Consent struct {
@@ -21,3 +23,125 @@ func (a *API) Consent(ctx context.Context, sessionID string, opts ...Optional) (
 	_, err := a.do(req, http.StatusOK, c)
 	return c, err
 }
+
+// DefaultConsentValidity is how long a consent is assumed to be valid, as per the PSD2 RTS
+// re-authentication rule. The Neonomics platform doesn't report when a consent expires.
+// To revoke a consent, e.g. when the end-user disconnects a bank, delete its session.
+const DefaultConsentValidity = 180 * 24 * time.Hour
+
+// ConsentTracker reports the consents of the sessions kept by a SessionManager, so the end-users
+// can be asked to re-authorize before the data access breaks.
+//
+// Only the sessions which no longer exist are detected by default, as the session status doesn't tell
+// whether the consent is valid. Set Probe to check the consents with SessionHealth instead,
+// or record the consent state of the stored sessions yourself, e.g. from SessionHealth.ConsentState.
+type ConsentTracker struct {
+	Sessions *SessionManager
+	Validity time.Duration // Assumed consent validity. Defaults to DefaultConsentValidity.
+	Probe    bool          // Whether to check the consents with SessionHealth, reaching the banks.
+}
+
+type TrackedConsent struct {
+	Session   *StoredSession
+	ExpiresAt time.Time // When the consent expires, or the zero time if it isn't granted.
+	Err       error     // The error which occurred while checking the consent, if any.
+}
+
+// NewConsentTracker creates a consent tracker for the sessions kept by the given session manager.
+func NewConsentTracker(sessions *SessionManager) *ConsentTracker {
+	return &ConsentTracker{Sessions: sessions}
+}
+
+// Scan checks the consent of every stored session and updates its consent state accordingly.
+// A consent seen granted for the first time is assumed to date from when the session was created,
+// as it can't be any older, or from the scan if it was seen missing before.
+func (t *ConsentTracker) Scan(ctx context.Context) ([]*TrackedConsent, error) {
+	m := t.Sessions
+	ss, err := m.Store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validity := t.Validity
+	if validity <= 0 {
+		validity = DefaultConsentValidity
+	}
+	tracked := make([]*TrackedConsent, 0, len(ss))
+	for _, s := range ss {
+		state, err := t.check(ctx, s.ID)
+		if err != nil {
+			tracked = append(tracked, &TrackedConsent{Session: s, Err: err})
+			continue
+		}
+		now := time.Now()
+		s, err = m.update(ctx, s, func(s *StoredSession) {
+			recordConsent(s, state, now, validity)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			continue // Deleted in the meantime.
+		}
+		tc := &TrackedConsent{Session: s}
+		if s.Consent == ConsentGranted {
+			tc.ExpiresAt = s.ConsentExpiresAt
+			if tc.ExpiresAt.IsZero() {
+				tc.ExpiresAt = s.CreatedAt.Add(validity)
+			}
+		}
+		tracked = append(tracked, tc)
+	}
+	return tracked, nil
+}
+
+// check returns the consent state of the given session, or ConsentUnknown if it isn't probed.
+func (t *ConsentTracker) check(ctx context.Context, sessionID string) (ConsentState, error) {
+	api := t.Sessions.API
+	if t.Probe {
+		h, err := api.SessionHealth(ctx, sessionID)
+		if err != nil {
+			return ConsentUnknown, err
+		}
+		return h.ConsentState(), nil
+	}
+	_, err := api.Status(ctx, sessionID)
+	if IsSessionExpired(err) {
+		return ConsentExpired, nil
+	}
+	return ConsentUnknown, err
+}
+
+// recordConsent updates the consent fields of the stored session with the checked state.
+func recordConsent(s *StoredSession, state ConsentState, now time.Time, validity time.Duration) {
+	switch {
+	case state == ConsentUnknown:
+		// Not checked, so the recorded state stands.
+	case state != ConsentGranted:
+		s.Consent, s.ConsentExpiresAt = state, time.Time{}
+	case s.Consent == ConsentGranted && !s.ConsentExpiresAt.IsZero():
+		// Still the consent granted before.
+	case s.Consent == ConsentRequired || s.Consent == ConsentExpired:
+		s.Consent, s.ConsentExpiresAt = ConsentGranted, now.Add(validity)
+	default:
+		s.Consent, s.ConsentExpiresAt = ConsentGranted, s.CreatedAt.Add(validity)
+	}
+}
+
+// Expiring returns the granted consents expiring within the given number of days, soonest first.
+func (t *ConsentTracker) Expiring(ctx context.Context, days int) ([]*TrackedConsent, error) {
+	tracked, err := t.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().AddDate(0, 0, days)
+	expiring := make([]*TrackedConsent, 0, len(tracked))
+	for _, tc := range tracked {
+		if !tc.ExpiresAt.IsZero() && tc.ExpiresAt.Before(deadline) {
+			expiring = append(expiring, tc)
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiresAt.Before(expiring[j].ExpiresAt)
+	})
+	return expiring, nil
+}
@@ -0,0 +1,135 @@
+package neo_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+func TestConsentTracker(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Now()
+	var m *neo.SessionManager
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		id := r.Header.Get(neo.HeaderSessionID)
+		switch {
+		case r.URL.Path == "/ics/v3/session/gone":
+			return response(520, `{"type":"SESSION","errorCode":"1001"}`, nil), nil
+		case strings.HasPrefix(r.URL.Path, "/ics/v3/session/"):
+			return response(http.StatusOK, `{"bankId":"bank"}`, nil), nil
+		case id == "required":
+			return response(510, consentError, nil), nil
+		case id == "down":
+			return response(http.StatusInternalServerError, `{}`, nil), nil
+		case id == "busy":
+			// The session is used while its consent is checked.
+			if _, err := m.Session(ctx, "bank", "busy"); err != nil {
+				t.Error(err)
+			}
+		}
+		return response(http.StatusOK, `[]`, nil), nil
+	})
+	store := neo.NewMemorySessionStore()
+	for _, s := range []*neo.StoredSession{
+		// Granted when the session was created.
+		{ID: "new", CreatedAt: now.AddDate(0, 0, -170)},
+		{ID: "kept", Consent: neo.ConsentGranted, ConsentExpiresAt: now.AddDate(0, 0, 5)},
+		// Granted again since the last scan.
+		{ID: "regranted", Consent: neo.ConsentRequired, CreatedAt: now.AddDate(0, 0, -170)},
+		{ID: "required", Consent: neo.ConsentGranted, ConsentExpiresAt: now.AddDate(0, 0, 5)},
+		{ID: "gone", Consent: neo.ConsentGranted},
+		{ID: "down", Consent: neo.ConsentGranted, ConsentExpiresAt: now.AddDate(0, 0, 60)},
+		{ID: "busy", BankID: "bank", PSU: "busy", CreatedAt: now},
+	} {
+		if err := store.Put(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m = neo.NewSessionManager(fakeAPI(d), store)
+	tracker := neo.NewConsentTracker(m)
+	tracker.Probe = true
+
+	expiring, err := tracker.Expiring(ctx, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, tc := range expiring {
+		ids = append(ids, tc.Session.ID)
+	}
+	if strings.Join(ids, ",") != "kept,new" {
+		t.Fatalf("TestConsentTracker: expected kept,new to expire within 30 days, got %v", ids)
+	}
+	for id, want := range map[string]neo.ConsentState{
+		"new":       neo.ConsentGranted,
+		"kept":      neo.ConsentGranted,
+		"regranted": neo.ConsentGranted,
+		"required":  neo.ConsentRequired,
+		"gone":      neo.ConsentExpired,
+		"down":      neo.ConsentGranted,
+		"busy":      neo.ConsentGranted,
+	} {
+		s, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Consent != want || (want == neo.ConsentGranted) == s.ConsentExpiresAt.IsZero() {
+			t.Fatalf("TestConsentTracker: %s: expected %q, got %q expiring at %s", id, want, s.Consent, s.ConsentExpiresAt)
+		}
+	}
+	if s, _ := store.Get(ctx, "regranted"); s.ConsentExpiresAt.Before(now.Add(neo.DefaultConsentValidity)) {
+		t.Fatalf("TestConsentTracker: expected the consent granted again to be valid from the scan, got %s", s.ConsentExpiresAt)
+	}
+	if s, _ := store.Get(ctx, "busy"); s.LastUsedAt.IsZero() {
+		t.Fatal("TestConsentTracker: the scan overwrote the session used meanwhile")
+	}
+
+	tracked, err := tracker.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range tracked {
+		if (tc.Session.ID == "down") != (tc.Err != nil) {
+			t.Fatalf("TestConsentTracker: %s: unexpected error %v", tc.Session.ID, tc.Err)
+		}
+	}
+}
+
+func TestConsentTrackerStatus(t *testing.T) {
+	ctx := context.TODO()
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/ics/v3/session/gone" {
+			return response(520, `{"type":"SESSION","errorCode":"1001"}`, nil), nil
+		}
+		if !strings.HasPrefix(r.URL.Path, "/ics/v3/session/") {
+			t.Errorf("TestConsentTrackerStatus: the bank was reached: %s", r.URL)
+		}
+		return response(http.StatusOK, `{"bankId":"bank"}`, nil), nil
+	})
+	store := neo.NewMemorySessionStore()
+	for i, id := range []string{"unknown", "required", "gone"} {
+		s := &neo.StoredSession{ID: id, CreatedAt: time.Now().Add(time.Duration(i) * time.Second)}
+		if id == "required" {
+			s.Consent = neo.ConsentRequired
+		}
+		if err := store.Put(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := neo.NewConsentTracker(neo.NewSessionManager(fakeAPI(d), store)).Scan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]neo.ConsentState{
+		"unknown":  neo.ConsentUnknown,
+		"required": neo.ConsentRequired,
+		"gone":     neo.ConsentExpired,
+	} {
+		if s, err := store.Get(ctx, id); err != nil || s.Consent != want {
+			t.Fatalf("TestConsentTrackerStatus: %s: expected %q, got %+v, %v", id, want, s, err)
+		}
+	}
+}
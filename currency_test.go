@@ -0,0 +1,35 @@
+package neo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/enfunc/neo"
+)
+
+func TestCurrencies(t *testing.T) {
+	for code, units := range map[string]int{"NOK": 2, "jpy": 0, "KWD": 3, "CLF": 4} {
+		c, ok := neo.LookupCurrency(code)
+		if !ok {
+			t.Fatalf("TestCurrencies: %s not found", code)
+		}
+		if c.MinorUnits != units {
+			t.Fatalf("TestCurrencies: %s has %d minor units, not %d", code, c.MinorUnits, units)
+		}
+	}
+	if neo.CurrencySEK.Name() != "Swedish Krona" {
+		t.Fatalf("TestCurrencies: unexpected name %s", neo.CurrencySEK.Name())
+	}
+	if err := neo.Currency("XYZ").OK(); !errors.Is(err, neo.ErrInvalidCurrency) {
+		t.Fatal("TestCurrencies: XYZ should be invalid")
+	}
+	if err := neo.Currency("nok").OK(); err != nil || neo.Currency("nok").MinorUnits() != 2 {
+		t.Fatalf("TestCurrencies: expected nok to be valid like NOK, got %v", err)
+	}
+	cs := neo.Currencies()
+	for i := 1; i < len(cs); i++ {
+		if cs[i-1].Code >= cs[i].Code {
+			t.Fatalf("TestCurrencies: %s and %s are out of order", cs[i-1].Code, cs[i].Code)
+		}
+	}
+}
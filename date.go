@@ -0,0 +1,256 @@
+package neo
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Date is a calendar date, such as the booking date of a transaction.
+// The zero value is a valid, empty date.
+//
+// Banks report dates in many formats, some of them as instants in UTC, e.g. "2022-02-28T23:00:00Z"
+// for a transaction booked on March 1st in Norway. A date parsed from a timestamp remembers the instant,
+// so that Localize can return the date in the time zone of the bank.
+type Date struct {
+	year  int
+	month time.Month
+	day   int
+	at    time.Time // The instant the date was parsed from, if any.
+}
+
+// NewDate returns the date of the given year, month & day, normalizing them like time.Date does.
+func NewDate(year int, month time.Month, day int) Date {
+	return DateOf(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// DateOf returns the date of t in its own location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{year: y, month: m, day: d}
+}
+
+// Today returns the current date in the given location.
+func Today(loc *time.Location) Date {
+	return DateOf(time.Now().In(loc))
+}
+
+// dateLayouts are the formats of the dates without a time zone.
+var dateLayouts = []string{
+	"2006-01-02",
+	"20060102",
+	"2006/01/02",
+	"02.01.2006",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+// ParseDate parses a date in any of the formats used by the banks: RFC 3339 timestamps,
+// ISO 8601 dates (2006-01-02 or 20060102), 2006/01/02, 02.01.2006, timestamps without a time zone,
+// and Unix timestamps in milliseconds.
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		d := DateOf(t)
+		d.at = t
+		return d, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return DateOf(t), nil
+		}
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) > 8 {
+		t := time.UnixMilli(ms).UTC()
+		d := DateOf(t)
+		d.at = t
+		return d, nil
+	}
+	return Date{}, fmt.Errorf("neo: %w: %q", ErrInvalidDate, s)
+}
+
+// MustParseDate is like ParseDate, but panics if the date is invalid.
+func MustParseDate(s string) Date {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Localize returns the date in the time zone of the given ISO 3166 country, e.g. "NO".
+// This only affects dates parsed from a timestamp, see CountryLocation.
+func (d Date) Localize(country string) Date {
+	if d.at.IsZero() {
+		return d
+	}
+	l := DateOf(d.at.In(CountryLocation(country)))
+	l.at = d.at
+	return l
+}
+
+// Year returns the year of the date.
+func (d Date) Year() int {
+	return d.year
+}
+
+// Month returns the month of the date.
+func (d Date) Month() time.Month {
+	return d.month
+}
+
+// Day returns the day of the month.
+func (d Date) Day() int {
+	return d.day
+}
+
+// IsZero reports whether the date is empty.
+func (d Date) IsZero() bool {
+	return d.year == 0 && d.month == 0 && d.day == 0
+}
+
+// In returns the start of the date in the given location.
+func (d Date) In(loc *time.Location) time.Time {
+	if d.IsZero() {
+		return time.Time{}
+	}
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns the date n days later, or earlier if n is negative.
+func (d Date) AddDays(n int) Date {
+	return NewDate(d.year, d.month, d.day+n)
+}
+
+// Compare returns -1 if d is before e, 0 if they're the same date and +1 if d is after e.
+func (d Date) Compare(e Date) int {
+	switch {
+	case d.year != e.year:
+		return sign(d.year - e.year)
+	case d.month != e.month:
+		return sign(int(d.month) - int(e.month))
+	}
+	return sign(d.day - e.day)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether d and e are the same date.
+func (d Date) Equal(e Date) bool {
+	return d.Compare(e) == 0
+}
+
+// Before reports whether d is before e.
+func (d Date) Before(e Date) bool {
+	return d.Compare(e) < 0
+}
+
+// After reports whether d is after e.
+func (d Date) After(e Date) bool {
+	return d.Compare(e) > 0
+}
+
+// String formats the date as 2006-01-02, or returns an empty string if the date is empty.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.year, d.month, d.day)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Date{}
+		return nil
+	}
+	p, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = p
+	return nil
+}
+
+// MarshalJSON encodes the date as 2006-01-02, as expected by the Neonomics platform, or null if it's empty.
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes the date from a JSON string, or from a JSON number holding a Unix timestamp in milliseconds.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	return d.UnmarshalText(bytes.Trim(data, `"`))
+}
+
+// countryZones are the time zones of the countries served by the Neonomics platform.
+// Countries spanning several time zones use the one of their capital.
+var countryZones = map[string]string{
+	"AT": "Europe/Vienna",
+	"BE": "Europe/Brussels",
+	"DE": "Europe/Berlin",
+	"DK": "Europe/Copenhagen",
+	"EE": "Europe/Tallinn",
+	"ES": "Europe/Madrid",
+	"FI": "Europe/Helsinki",
+	"FR": "Europe/Paris",
+	"GB": "Europe/London",
+	"IE": "Europe/Dublin",
+	"IS": "Atlantic/Reykjavik",
+	"IT": "Europe/Rome",
+	"LT": "Europe/Vilnius",
+	"LU": "Europe/Luxembourg",
+	"LV": "Europe/Riga",
+	"NL": "Europe/Amsterdam",
+	"NO": "Europe/Oslo",
+	"PL": "Europe/Warsaw",
+	"PT": "Europe/Lisbon",
+	"SE": "Europe/Stockholm",
+}
+
+// countryLocations caches the loaded time zones, as loading reads the time zone database.
+var countryLocations = struct {
+	sync.Mutex
+	m map[string]*time.Location // Keyed by the time zone.
+}{m: make(map[string]*time.Location)}
+
+// CountryLocation returns the time zone of the given ISO 3166 country.
+// It falls back to UTC for unknown countries, or if the time zone database is unavailable.
+// Import time/tzdata to embed the database into the binary.
+func CountryLocation(country string) *time.Location {
+	zone, ok := countryZones[strings.ToUpper(country)]
+	if !ok {
+		return time.UTC
+	}
+	countryLocations.Lock()
+	defer countryLocations.Unlock()
+	if loc, ok := countryLocations.m[zone]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		loc = time.UTC
+	}
+	countryLocations.m[zone] = loc
+	return loc
+}
@@ -0,0 +1,78 @@
+package neo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // CountryLocation needs the time zone database.
+
+	"github.com/enfunc/neo"
+)
+
+func TestParseDate(t *testing.T) {
+	for s, want := range map[string]string{
+		"2022-03-01":                "2022-03-01",
+		"20220301":                  "2022-03-01",
+		"2022/03/01":                "2022-03-01",
+		"01.03.2022":                "2022-03-01",
+		"2022-03-01T10:15:00":       "2022-03-01",
+		"2022-03-01 10:15:00":       "2022-03-01",
+		"2022-03-01T00:30:00+01:00": "2022-03-01",
+		"1646092800000":             "2022-03-01",
+	} {
+		d, err := neo.ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.String() != want {
+			t.Errorf("TestParseDate: %q: expected %s, got %s", s, want, d)
+		}
+	}
+	for _, s := range []string{"2022-13-01", "1.3.22", "yesterday"} {
+		if _, err := neo.ParseDate(s); !errors.Is(err, neo.ErrInvalidDate) {
+			t.Errorf("TestParseDate: %q: expected ErrInvalidDate, got %v", s, err)
+		}
+	}
+}
+
+func TestDateLocalize(t *testing.T) {
+	d := neo.MustParseDate("2022-02-28T23:00:00Z")
+	if d.String() != "2022-02-28" || d.Localize("NO").String() != "2022-03-01" || d.Localize("GB").String() != "2022-02-28" {
+		t.Fatalf("TestDateLocalize: unexpected dates %s, %s, %s", d, d.Localize("NO"), d.Localize("GB"))
+	}
+	if d := neo.MustParseDate("2022-02-28"); !d.Localize("NO").Equal(d) {
+		t.Fatal("TestDateLocalize: dates without a time should not be localized")
+	}
+	if got := neo.NewDate(2022, time.February, 28).AddDays(1); !got.Equal(neo.NewDate(2022, time.March, 1)) {
+		t.Fatalf("TestDateLocalize: unexpected next day %s", got)
+	}
+	if loc := neo.CountryLocation("NO"); loc.String() != "Europe/Oslo" || neo.CountryLocation("no") != loc {
+		t.Fatalf("TestDateLocalize: expected the cached Europe/Oslo, got %s", loc)
+	}
+	if neo.CountryLocation("XX") != time.UTC {
+		t.Fatal("TestDateLocalize: expected UTC for an unknown country")
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	tx := &neo.Tx{}
+	if err := json.Unmarshal([]byte(`{"bookingDate":"2022-03-01","valueDate":null}`), tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.BookingDate == nil || tx.BookingDate.String() != "2022-03-01" || tx.ValueDate != nil {
+		t.Fatalf("TestDateJSON: unexpected dates %v, %v", tx.BookingDate, tx.ValueDate)
+	}
+	d := neo.MustParseDate("2022-03-01T10:00:00+01:00")
+	data, err := json.Marshal(&neo.PaymentRequest{RequestedExecutionDate: &d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := map[string]interface{}{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r["requestedExecutionDate"] != "2022-03-01" {
+		t.Fatalf("TestDateJSON: expected a date-only execution date, got %v", r["requestedExecutionDate"])
+	}
+}
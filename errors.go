@@ -11,7 +11,15 @@ var (
 	ErrInvalidBankID      = errors.New("invalid bank ID")
 	ErrInvalidSessionID   = errors.New("invalid session ID")
 	ErrInvalidAccountID   = errors.New("invalid account ID")
+	ErrInvalidTxID        = errors.New("invalid transaction ID")
 	ErrInvalidSCAData     = errors.New("invalid SCA data")
+	ErrSCAExpired         = errors.New("SCA expired")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrInvalidAmount      = errors.New("invalid amount")
+	ErrInvalidCurrency    = errors.New("invalid currency")
+	ErrInvalidPagination  = errors.New("invalid pagination")
+	ErrWatermarkNotFound  = errors.New("watermark not found")
+	ErrInvalidDate        = errors.New("invalid date")
 )
 
 type Error struct {
@@ -28,6 +36,21 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%s error %s: %s", e.Type, e.ErrorCode, e.Message)
 }
 
+// PartialError is returned if only some pages of a paginated response could be retrieved.
+// The results of the retrieved pages are returned alongside it.
+type PartialError struct {
+	Pages int   // The number of retrieved pages.
+	Err   error // The reason the next page couldn't be retrieved.
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("neo: partial result after %d pages: %v", e.Pages, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
 func (e *Error) IsConsentError() bool {
 	return e != nil && e.Type == "CONSENT" && e.ErrorCode == "1426"
 }
@@ -35,3 +58,7 @@ func (e *Error) IsConsentError() bool {
 func (e *Error) IsPaymentAuthError() bool {
 	return e != nil && e.Type == "CONSENT" && e.ErrorCode == "1428"
 }
+
+func (e *Error) IsSessionError() bool {
+	return e != nil && e.Type == "SESSION"
+}
@@ -0,0 +1,391 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// CAMT053 is the ISO 20022 bank to customer statement, camt.053.001.02.
+type CAMT053 struct{}
+
+type camtDocument struct {
+	XMLName xml.Name      `xml:"Document"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	MsgID   string        `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	CreDtTm string        `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	Stmt    camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID      string        `xml:"Id"`
+	CreDtTm string        `xml:"CreDtTm"`
+	FrToDt  *camtPeriod   `xml:"FrToDt,omitempty"`
+	Acct    camtAccount   `xml:"Acct"`
+	Bal     []camtBalance `xml:"Bal"`
+	Ntry    []camtEntry   `xml:"Ntry"`
+}
+
+type camtPeriod struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID   camtAccountID `xml:"Id"`
+	Ccy  string        `xml:"Ccy,omitempty"`
+	Nm   string        `xml:"Nm,omitempty"`
+	Ownr *camtParty    `xml:"Ownr,omitempty"`
+}
+
+type camtAccountID struct {
+	IBAN string     `xml:"IBAN,omitempty"`
+	Othr *camtOther `xml:"Othr,omitempty"`
+}
+
+type camtOther struct {
+	ID string `xml:"Id"`
+}
+
+type camtParty struct {
+	Nm string `xml:"Nm"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+// camtDate is either a date or a timestamp.
+type camtDate struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm,omitempty"`
+}
+
+type camtBalance struct {
+	Tp        string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        camtDate   `xml:"Dt"`
+}
+
+type camtEntry struct {
+	NtryRef     string        `xml:"NtryRef,omitempty"`
+	Amt         camtAmount    `xml:"Amt"`
+	CdtDbtInd   string        `xml:"CdtDbtInd"`
+	Sts         string        `xml:"Sts"`
+	BookgDt     *camtDate     `xml:"BookgDt,omitempty"`
+	ValDt       *camtDate     `xml:"ValDt,omitempty"`
+	AcctSvcrRef string        `xml:"AcctSvcrRef,omitempty"`
+	BkTxCd      *camtTxCode   `xml:"BkTxCd>Domn,omitempty"`
+	TxDtls      camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTxCode struct {
+	Cd        string `xml:"Cd"`
+	FmlyCd    string `xml:"Fmly>Cd"`
+	SubFmlyCd string `xml:"Fmly>SubFmlyCd"`
+}
+
+// The optional elements are pointers, as encoding/xml writes the parents of an omitted a>b element.
+type camtTxDetails struct {
+	Refs      *camtRefs       `xml:"Refs,omitempty"`
+	RltdPties *camtParties    `xml:"RltdPties,omitempty"`
+	RmtInf    *camtRemittance `xml:"RmtInf,omitempty"`
+}
+
+type camtRefs struct {
+	EndToEndID string `xml:"EndToEndId"`
+}
+
+type camtParties struct {
+	Dbtr     *camtParty     `xml:"Dbtr,omitempty"`
+	DbtrAcct *camtAccountID `xml:"DbtrAcct>Id,omitempty"`
+	Cdtr     *camtParty     `xml:"Cdtr,omitempty"`
+	CdtrAcct *camtAccountID `xml:"CdtrAcct>Id,omitempty"`
+}
+
+type camtRemittance struct {
+	Ustrd []string         `xml:"Ustrd,omitempty"`
+	Strd  *camtCreditorRef `xml:"Strd>CdtrRefInf,omitempty"`
+}
+
+type camtCreditorRef struct {
+	Tp  *camtCode `xml:"Tp>CdOrPrtry,omitempty"`
+	Ref string    `xml:"Ref"`
+}
+
+type camtCode struct {
+	Cd string `xml:"Cd"`
+}
+
+const camtDateTime = "2006-01-02T15:04:05"
+
+func (CAMT053) Export(w io.Writer, s *Statement) error {
+	p, err := prepare(s)
+	if err != nil {
+		return err
+	}
+	doc := &camtDocument{
+		Xmlns:   camt053Namespace,
+		MsgID:   p.ID,
+		CreDtTm: p.CreatedAt.Format(camtDateTime),
+		Stmt: camtStatement{
+			ID:      p.ID,
+			CreDtTm: p.CreatedAt.Format(camtDateTime),
+			Acct: camtAccount{
+				ID:  camtAccountOf(accountNumber(p.Account)),
+				Ccy: string(p.Currency),
+				Nm:  p.Account.AccountName,
+			},
+			Bal: []camtBalance{
+				camtBalanceOf("OPBD", p.opening, p.Currency, p.From),
+				camtBalanceOf("CLBD", p.closing, p.Currency, p.To),
+			},
+		},
+	}
+	if !p.From.IsZero() {
+		doc.Stmt.FrToDt = &camtPeriod{FrDtTm: p.From.String() + "T00:00:00", ToDtTm: p.To.String() + "T23:59:59"}
+	}
+	if p.Account.OwnerName != "" {
+		doc.Stmt.Acct.Ownr = &camtParty{Nm: p.Account.OwnerName}
+	}
+	for _, tx := range p.booked {
+		doc.Stmt.Ntry = append(doc.Stmt.Ntry, camtEntryOf(tx, p.Currency))
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("export: failed to write camt.053: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("export: failed to write camt.053: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("export: failed to write camt.053: %w", err)
+	}
+	return nil
+}
+
+func (CAMT053) Import(r io.Reader) (*Statement, error) {
+	doc := &camtDocument{}
+	if err := xml.NewDecoder(r).Decode(doc); err != nil {
+		return nil, fmt.Errorf("export: %w: camt.053: %v", ErrInvalidFormat, err) //nolint:errorlint
+	}
+	st := &doc.Stmt
+	s := &Statement{
+		ID:       st.ID,
+		Account:  &neo.Account{AccountName: st.Acct.Nm},
+		Currency: neo.Currency(st.Acct.Ccy),
+	}
+	setAccountNumber(s.Account, st.Acct.ID.number())
+	if st.Acct.Ownr != nil {
+		s.Account.OwnerName = st.Acct.Ownr.Nm
+	}
+	created := st.CreDtTm
+	if created == "" {
+		created = doc.CreDtTm
+	}
+	if t, err := camtParseTime(created); err == nil {
+		s.CreatedAt = t
+	}
+	if st.FrToDt != nil {
+		s.From, _ = neo.ParseDate(st.FrToDt.FrDtTm)
+		s.To, _ = neo.ParseDate(st.FrToDt.ToDtTm)
+	}
+	for _, b := range st.Bal {
+		a, err := camtSigned(b.Amt, b.CdtDbtInd)
+		if err != nil {
+			return nil, err
+		}
+		bal := &neo.Balance{Amount: a, Currency: neo.Currency(b.Amt.Ccy)}
+		if d := b.Dt.date(); !d.IsZero() {
+			bal.ReferenceDate = &d
+		}
+		switch b.Tp {
+		case "OPBD":
+			bal.Type, s.Opening = neo.BalanceOpeningBooked, bal
+		case "CLBD":
+			bal.Type, s.Closing = neo.BalanceClosingBooked, bal
+		}
+	}
+	for _, e := range st.Ntry {
+		tx, err := e.tx()
+		if err != nil {
+			return nil, err
+		}
+		s.Txs = append(s.Txs, tx)
+	}
+	return s, nil
+}
+
+// camtParseTime parses an ISO 8601 timestamp, with or without the time zone & the fraction of a second.
+func camtParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(camtDateTime+".999999999", s)
+}
+
+// date returns the date, or the date of the timestamp in its own time zone.
+func (d *camtDate) date() neo.Date {
+	if d == nil {
+		return neo.Date{}
+	}
+	s := d.Dt
+	if s == "" {
+		s = d.DtTm
+	}
+	date, _ := neo.ParseDate(s)
+	return date
+}
+
+func camtSigned(a camtAmount, cd string) (neo.Amount, error) {
+	v, err := neo.ParseAmount(strings.TrimSpace(a.Value))
+	if err != nil {
+		return neo.Amount{}, fmt.Errorf("export: %w: camt.053: %v", ErrInvalidFormat, err) //nolint:errorlint
+	}
+	if cd == string(neo.Debit) {
+		return v.Abs().Neg(), nil
+	}
+	return v, nil
+}
+
+func (e *camtEntry) tx() (*neo.Tx, error) {
+	signed, err := camtSigned(e.Amt, e.CdtDbtInd)
+	if err != nil {
+		return nil, err
+	}
+	tx := newTx(signed, neo.Currency(e.Amt.Ccy))
+	tx.ID, tx.TransactionReference = e.NtryRef, e.AcctSvcrRef
+	if e.Sts == "PDNG" {
+		tx.Status = neo.TxPending
+	}
+	tx.BookingDate, tx.ValueDate = datePtr(e.BookgDt.date()), datePtr(e.ValDt.date())
+	if c := e.BkTxCd; c != nil {
+		tx.BankTransactionCode = c.Cd + "-" + c.FmlyCd + "-" + c.SubFmlyCd
+	}
+	d := &e.TxDtls
+	if d.Refs != nil && d.Refs.EndToEndID != notProvided {
+		tx.EndToEndIdentification = d.Refs.EndToEndID
+	}
+	if p := d.RltdPties; p != nil {
+		if p.Dbtr != nil {
+			tx.DebtorName = p.Dbtr.Nm
+		}
+		if p.Cdtr != nil {
+			tx.CreditorName = p.Cdtr.Nm
+		}
+		tx.DebtorAccount, tx.CreditorAccount = p.DbtrAcct.info(), p.CdtrAcct.info()
+		tx.CounterpartyName, tx.CounterpartyAccount = tx.CreditorName, p.CdtrAcct.number()
+		if signed.Sign() > 0 {
+			tx.CounterpartyName, tx.CounterpartyAccount = tx.DebtorName, p.DbtrAcct.number()
+		}
+	}
+	if r := d.RmtInf; r != nil {
+		tx.RemittanceInfoUnstructured = strings.Join(r.Ustrd, " ")
+		if r.Strd != nil {
+			tx.RemittanceInfoStructured = &neo.RemittanceInfoStructured{Reference: r.Strd.Ref}
+			if r.Strd.Tp != nil {
+				tx.RemittanceInfoStructured.Type = r.Strd.Tp.Cd
+			}
+		}
+	}
+	return tx, nil
+}
+
+func camtAccountOf(number string) camtAccountID {
+	if neo.ValidateIBAN(number) == nil {
+		return camtAccountID{IBAN: number}
+	}
+	return camtAccountID{Othr: &camtOther{ID: number}}
+}
+
+func (id *camtAccountID) number() string {
+	if id == nil {
+		return ""
+	}
+	if id.IBAN != "" || id.Othr == nil {
+		return id.IBAN
+	}
+	return id.Othr.ID
+}
+
+func (id *camtAccountID) info() *neo.AccountInfo {
+	if id == nil {
+		return nil
+	}
+	info := &neo.AccountInfo{IBAN: id.IBAN}
+	if id.Othr != nil {
+		info.BBAN = id.Othr.ID
+	}
+	return info
+}
+
+func camtBalanceOf(code string, a neo.Amount, currency neo.Currency, d neo.Date) camtBalance {
+	return camtBalance{
+		Tp:        code,
+		Amt:       camtAmount{Ccy: string(currency), Value: a.Abs().Format(currency)},
+		CdtDbtInd: string(creditDebit(a)),
+		Dt:        camtDate{Dt: d.String()},
+	}
+}
+
+func camtEntryOf(tx *neo.Tx, currency neo.Currency) camtEntry {
+	signed := tx.SignedAmount()
+	if tx.TransactionAmount != nil && tx.TransactionAmount.Currency != "" {
+		currency = tx.TransactionAmount.Currency
+	}
+	e := camtEntry{
+		NtryRef:     tx.ID,
+		Amt:         camtAmount{Ccy: string(currency), Value: signed.Abs().Format(currency)},
+		CdtDbtInd:   string(creditDebit(signed)),
+		Sts:         "BOOK",
+		AcctSvcrRef: tx.TransactionReference,
+	}
+	if tx.EndToEndIdentification != "" {
+		e.TxDtls.Refs = &camtRefs{EndToEndID: tx.EndToEndIdentification}
+	}
+	if tx.BookingDate != nil && !tx.BookingDate.IsZero() {
+		e.BookgDt = &camtDate{Dt: tx.BookingDate.String()}
+	}
+	if tx.ValueDate != nil && !tx.ValueDate.IsZero() {
+		e.ValDt = &camtDate{Dt: tx.ValueDate.String()}
+	}
+	if parts := strings.Split(tx.BankTransactionCode, "-"); len(parts) == 3 {
+		e.BkTxCd = &camtTxCode{Cd: parts[0], FmlyCd: parts[1], SubFmlyCd: parts[2]}
+	}
+	if name, account := counterparty(tx); name != "" || account != "" {
+		var party *camtParty
+		if name != "" {
+			party = &camtParty{Nm: name}
+		}
+		var acc *camtAccountID
+		if account != "" {
+			a := camtAccountOf(account)
+			acc = &a
+		}
+		if signed.Sign() > 0 {
+			e.TxDtls.RltdPties = &camtParties{Dbtr: party, DbtrAcct: acc}
+		} else {
+			e.TxDtls.RltdPties = &camtParties{Cdtr: party, CdtrAcct: acc}
+		}
+	}
+	switch {
+	case tx.RemittanceInfoUnstructured != "":
+		e.TxDtls.RmtInf = &camtRemittance{Ustrd: []string{tx.RemittanceInfoUnstructured}}
+	case tx.RemittanceInfoStructured != nil:
+		ref := &camtCreditorRef{Ref: tx.RemittanceInfoStructured.Reference}
+		if tx.RemittanceInfoStructured.Type != "" {
+			ref.Tp = &camtCode{Cd: tx.RemittanceInfoStructured.Type}
+		}
+		e.TxDtls.RmtInf = &camtRemittance{Strd: ref}
+	}
+	return e
+}
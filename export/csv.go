@@ -0,0 +1,304 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+// Column is a column of a CSV export.
+type Column string
+
+const (
+	ColumnID                  Column = "id"
+	ColumnBookingDate         Column = "bookingDate"
+	ColumnValueDate           Column = "valueDate"
+	ColumnAmount              Column = "amount" // The signed amount.
+	ColumnCurrency            Column = "currency"
+	ColumnCreditDebit         Column = "creditDebit"
+	ColumnStatus              Column = "status"
+	ColumnReference           Column = "reference"
+	ColumnCounterpartyName    Column = "counterpartyName"
+	ColumnCounterpartyAccount Column = "counterpartyAccount"
+	ColumnRemittance          Column = "remittance"
+	ColumnEndToEndID          Column = "endToEndId"
+	ColumnBankTxCode          Column = "bankTransactionCode"
+)
+
+// DefaultCSVColumns are the columns exported by default.
+var DefaultCSVColumns = []Column{
+	ColumnBookingDate,
+	ColumnValueDate,
+	ColumnAmount,
+	ColumnCurrency,
+	ColumnCounterpartyName,
+	ColumnCounterpartyAccount,
+	ColumnRemittance,
+	ColumnReference,
+	ColumnID,
+	ColumnStatus,
+}
+
+// CSV writes the transactions of the statement, one per row, after a header row.
+// The zero value writes the default columns, separated by ";", with ISO 8601 dates & decimal points.
+// The account & the balances aren't part of the export.
+type CSV struct {
+	Columns      []Column          // Defaults to DefaultCSVColumns.
+	Headers      map[Column]string // The header of each column. Defaults to the column name.
+	Comma        rune              // Defaults to ';'.
+	DateLayout   string            // A time.Format layout. Defaults to 2006-01-02.
+	DecimalComma bool              // Whether to write 12,50 instead of 12.50.
+	CRLF         bool              // Whether to end the lines with \r\n, as expected by Excel.
+	// Whether to write text starting with =, +, -, @, a tab or a CR as-is. By default, it's prefixed with '
+	// so that spreadsheets don't evaluate it as a formula, since the payers choose their names & remittance info.
+	Unescaped bool
+}
+
+// formulaPrefixes are the characters spreadsheets evaluate a cell starting with as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula prefixes text starting like a formula with ', which spreadsheets show as text.
+func escapeFormula(v string) string {
+	if v != "" && strings.IndexByte(formulaPrefixes, v[0]) >= 0 {
+		return "'" + v
+	}
+	return v
+}
+
+func unescapeFormula(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.IndexByte(formulaPrefixes, v[1]) >= 0 {
+		return v[1:]
+	}
+	return v
+}
+
+// isText reports whether the column holds text taken from the bank, rather than formatted by the export.
+func isText(col Column) bool {
+	switch col {
+	case ColumnID, ColumnReference, ColumnCounterpartyName, ColumnCounterpartyAccount,
+		ColumnRemittance, ColumnEndToEndID, ColumnBankTxCode:
+		return true
+	}
+	return false
+}
+
+func (c *CSV) columns() []Column {
+	if len(c.Columns) == 0 {
+		return DefaultCSVColumns
+	}
+	return c.Columns
+}
+
+func (c *CSV) header(col Column) string {
+	if h, ok := c.Headers[col]; ok {
+		return h
+	}
+	return string(col)
+}
+
+func (c *CSV) dateLayout() string {
+	if c.DateLayout == "" {
+		return "2006-01-02"
+	}
+	return c.DateLayout
+}
+
+func (c *CSV) writer(w io.Writer) *csv.Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	if c.Comma != 0 {
+		cw.Comma = c.Comma
+	}
+	cw.UseCRLF = c.CRLF
+	return cw
+}
+
+func (c *CSV) Export(w io.Writer, s *Statement) error {
+	if s == nil {
+		return fmt.Errorf("export: %w", ErrInvalidStatement)
+	}
+	cols := c.columns()
+	cw := c.writer(w)
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = c.header(col)
+	}
+	if err := cw.Write(row); err != nil {
+		return fmt.Errorf("export: failed to write CSV: %w", err)
+	}
+	for _, tx := range s.Txs {
+		if tx == nil {
+			continue
+		}
+		for i, col := range cols {
+			row[i] = c.value(tx, col, s.Currency)
+			if !c.Unescaped && isText(col) {
+				row[i] = escapeFormula(row[i])
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("export: failed to write CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("export: failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func (c *CSV) value(tx *neo.Tx, col Column, currency neo.Currency) string { //nolint:cyclop
+	if tx.TransactionAmount != nil && tx.TransactionAmount.Currency != "" {
+		currency = tx.TransactionAmount.Currency
+	}
+	date := func(d *neo.Date) string {
+		if d == nil || d.IsZero() {
+			return ""
+		}
+		return d.In(time.UTC).Format(c.dateLayout())
+	}
+	switch col {
+	case ColumnID:
+		return tx.ID
+	case ColumnBookingDate:
+		return date(tx.BookingDate)
+	case ColumnValueDate:
+		return date(tx.ValueDate)
+	case ColumnAmount:
+		a := tx.SignedAmount().Format(currency)
+		if c.DecimalComma {
+			a = strings.Replace(a, ".", ",", 1)
+		}
+		return a
+	case ColumnCurrency:
+		return string(currency)
+	case ColumnCreditDebit:
+		return string(creditDebit(tx.SignedAmount()))
+	case ColumnStatus:
+		return string(tx.Status)
+	case ColumnReference:
+		return tx.TransactionReference
+	case ColumnCounterpartyName:
+		name, _ := counterparty(tx)
+		return name
+	case ColumnCounterpartyAccount:
+		_, account := counterparty(tx)
+		return account
+	case ColumnRemittance:
+		return remittance(tx)
+	case ColumnEndToEndID:
+		return tx.EndToEndIdentification
+	case ColumnBankTxCode:
+		return tx.BankTransactionCode
+	}
+	return ""
+}
+
+// Import reads the transactions of a CSV export. The columns are identified by their headers.
+func (c *CSV) Import(r io.Reader) (*Statement, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = ';'
+	if c.Comma != 0 {
+		cr.Comma = c.Comma
+	}
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("export: %w: CSV: %v", ErrInvalidFormat, err) //nolint:errorlint
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("export: %w: CSV without a header", ErrInvalidFormat)
+	}
+	byHeader := make(map[string]Column)
+	for _, col := range append(append([]Column{}, c.columns()...), allColumns...) {
+		if _, ok := byHeader[c.header(col)]; !ok {
+			byHeader[c.header(col)] = col
+		}
+	}
+	cols := make([]Column, len(rows[0]))
+	for i, h := range rows[0] {
+		cols[i] = byHeader[h]
+	}
+	s := &Statement{}
+	for _, row := range rows[1:] {
+		tx := &neo.Tx{TransactionAmount: &neo.Money{}}
+		for i, v := range row {
+			if !c.Unescaped && isText(cols[i]) {
+				v = unescapeFormula(v)
+			}
+			if err := c.set(tx, cols[i], v); err != nil {
+				return nil, err
+			}
+		}
+		if tx.Status == "" {
+			tx.Status = neo.TxBooked
+			if tx.BookingDate == nil {
+				tx.Status = neo.TxPending
+			}
+		}
+		if s.Currency == "" {
+			s.Currency = tx.TransactionAmount.Currency
+		}
+		s.Txs = append(s.Txs, tx)
+	}
+	return s, nil
+}
+
+var allColumns = []Column{
+	ColumnID, ColumnBookingDate, ColumnValueDate, ColumnAmount, ColumnCurrency, ColumnCreditDebit, ColumnStatus,
+	ColumnReference, ColumnCounterpartyName, ColumnCounterpartyAccount, ColumnRemittance, ColumnEndToEndID, ColumnBankTxCode,
+}
+
+func (c *CSV) set(tx *neo.Tx, col Column, v string) error { //nolint:cyclop
+	date := func(v string) (*neo.Date, error) {
+		if v == "" {
+			return nil, nil
+		}
+		t, err := time.Parse(c.dateLayout(), v)
+		if err != nil {
+			return nil, fmt.Errorf("export: %w: CSV date %q", ErrInvalidFormat, v)
+		}
+		d := neo.DateOf(t)
+		return &d, nil
+	}
+	var err error
+	switch col {
+	case ColumnID:
+		tx.ID = v
+	case ColumnBookingDate:
+		tx.BookingDate, err = date(v)
+	case ColumnValueDate:
+		tx.ValueDate, err = date(v)
+	case ColumnAmount:
+		if c.DecimalComma {
+			v = strings.Replace(v, ",", ".", 1)
+		}
+		a, perr := neo.ParseAmount(v)
+		if perr != nil {
+			return fmt.Errorf("export: %w: CSV amount %q", ErrInvalidFormat, v)
+		}
+		tx.TransactionAmount.Value, tx.CreditDebitIndicator = a.Abs(), creditDebit(a)
+	case ColumnCurrency:
+		tx.TransactionAmount.Currency = neo.Currency(v)
+	case ColumnCreditDebit:
+		err = tx.CreditDebitIndicator.UnmarshalText([]byte(v))
+	case ColumnStatus:
+		err = tx.Status.UnmarshalText([]byte(v))
+	case ColumnReference:
+		tx.TransactionReference = v
+	case ColumnCounterpartyName:
+		tx.CounterpartyName = v
+	case ColumnCounterpartyAccount:
+		tx.CounterpartyAccount = v
+	case ColumnRemittance:
+		tx.RemittanceInfoUnstructured = v
+	case ColumnEndToEndID:
+		tx.EndToEndIdentification = v
+	case ColumnBankTxCode:
+		tx.BankTransactionCode = v
+	}
+	return err
+}
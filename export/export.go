@@ -0,0 +1,220 @@
+// Package export writes account statements in the formats expected by ERP & accounting tools:
+// ISO 20022 camt.053, SWIFT MT940, OFX 2.x & CSV. Every format can also be read back.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+var (
+	ErrInvalidStatement = errors.New("invalid statement")
+	ErrNoBalance        = errors.New("no opening or closing balance")
+	ErrInvalidFormat    = errors.New("invalid format")
+)
+
+var (
+	_ Format = CAMT053{}
+	_ Format = MT940{}
+	_ Format = OFX{}
+	_ Format = &CSV{}
+)
+
+// Format writes & reads statements in a specific file format.
+type Format interface {
+	// Export writes the statement to w.
+	Export(w io.Writer, s *Statement) error
+	// Import reads a statement written in the format. The fields the format doesn't hold are left empty.
+	Import(r io.Reader) (*Statement, error)
+}
+
+// Statement is the account statement to export.
+type Statement struct {
+	ID        string    // The statement identification, e.g. "2022-03".
+	CreatedAt time.Time // Defaults to the current time.
+	Account   *neo.Account
+	Currency  neo.Currency // Defaults to the currency of the balances or the transactions.
+	From, To  neo.Date     // The period of the statement. Defaults to the booking dates of the transactions.
+
+	// The booked balances at the start & end of the period. If only one of them is known,
+	// the other one is computed from the transactions.
+	Opening *neo.Balance
+	Closing *neo.Balance
+
+	// The transactions of the period. Except for CSV, which includes a status column,
+	// the formats only hold booked transactions, so the pending ones are left out.
+	Txs []*neo.Tx
+}
+
+// statement is a statement with the defaults filled in.
+type statement struct {
+	*Statement
+	opening, closing neo.Amount
+	booked           []*neo.Tx
+}
+
+func prepare(s *Statement) (*statement, error) {
+	if s == nil || s.Account == nil {
+		return nil, fmt.Errorf("export: %w: no account", ErrInvalidStatement)
+	}
+	c := *s
+	p := &statement{Statement: &c}
+	for _, tx := range s.Txs {
+		if tx != nil && tx.Status != neo.TxPending {
+			p.booked = append(p.booked, tx)
+		}
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	if c.ID == "" {
+		c.ID = c.CreatedAt.Format("20060102150405")
+	}
+	if c.Currency == "" {
+		c.Currency = p.currency()
+	}
+	for _, tx := range p.booked {
+		if d := tx.BookingDate; d != nil && !d.IsZero() {
+			if c.From.IsZero() || d.Before(c.From) {
+				c.From = *d
+			}
+			if c.To.IsZero() || d.After(c.To) {
+				c.To = *d
+			}
+		}
+	}
+	var sum neo.Amount
+	for _, tx := range p.booked {
+		sum = sum.Add(tx.SignedAmount())
+	}
+	switch {
+	case c.Opening != nil:
+		p.opening = c.Opening.Amount
+		p.closing = p.opening.Add(sum)
+		if c.Closing != nil {
+			p.closing = c.Closing.Amount
+		}
+	case c.Closing != nil:
+		p.closing = c.Closing.Amount
+		p.opening = p.closing.Sub(sum)
+	default:
+		return nil, fmt.Errorf("export: %w", ErrNoBalance)
+	}
+	return p, nil
+}
+
+func (s *statement) currency() neo.Currency {
+	for _, b := range []*neo.Balance{s.Opening, s.Closing} {
+		if b != nil && b.Currency != "" {
+			return b.Currency
+		}
+	}
+	for _, tx := range s.Txs {
+		if tx != nil && tx.TransactionAmount != nil && tx.TransactionAmount.Currency != "" {
+			return tx.TransactionAmount.Currency
+		}
+	}
+	return ""
+}
+
+// accountNumber returns the IBAN of the account, or its BBAN or sort code & account number.
+func accountNumber(acc *neo.Account) string {
+	switch {
+	case acc.IBAN != "":
+		return neo.NormalizeIBAN(acc.IBAN)
+	case acc.BBAN != "":
+		return neo.NormalizeBBAN(acc.BBAN)
+	}
+	return neo.NormalizeSortCodeAccountNumber(acc.SortCodeAccountNumber)
+}
+
+// setAccountNumber is the inverse of accountNumber.
+func setAccountNumber(acc *neo.Account, number string) {
+	if neo.ValidateIBAN(number) == nil {
+		acc.IBAN = number
+	} else {
+		acc.BBAN = number
+	}
+}
+
+// counterparty returns the name & account number of the other party of the transaction.
+func counterparty(tx *neo.Tx) (name, account string) {
+	name, account = tx.CounterpartyName, tx.CounterpartyAccount
+	other, otherAccount := tx.CreditorName, tx.CreditorAccount
+	if tx.SignedAmount().Sign() > 0 {
+		other, otherAccount = tx.DebtorName, tx.DebtorAccount
+	}
+	if name == "" {
+		name = other
+	}
+	if account == "" && otherAccount != nil {
+		account = otherAccount.IBAN
+		if account == "" {
+			account = otherAccount.BBAN
+		}
+	}
+	return name, account
+}
+
+// remittance returns the unstructured remittance info, or the structured reference.
+func remittance(tx *neo.Tx) string {
+	if tx.RemittanceInfoUnstructured != "" || tx.RemittanceInfoStructured == nil {
+		return tx.RemittanceInfoUnstructured
+	}
+	return tx.RemittanceInfoStructured.Reference
+}
+
+// notProvided is the placeholder the banks send for a missing end-to-end ID.
+const notProvided = "NOTPROVIDED"
+
+// newTx creates a booked transaction of the given signed amount.
+func newTx(signed neo.Amount, currency neo.Currency) *neo.Tx {
+	cd := neo.Credit
+	if signed.Sign() < 0 {
+		cd = neo.Debit
+	}
+	return &neo.Tx{
+		TransactionAmount:    &neo.Money{Currency: currency, Value: signed.Abs()},
+		CreditDebitIndicator: cd,
+		Status:               neo.TxBooked,
+	}
+}
+
+func creditDebit(a neo.Amount) neo.CreditDebit {
+	if a.Sign() < 0 {
+		return neo.Debit
+	}
+	return neo.Credit
+}
+
+func datePtr(d neo.Date) *neo.Date {
+	if d.IsZero() {
+		return nil
+	}
+	return &d
+}
+
+func truncate(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) > n {
+		r = r[:n]
+	}
+	return string(r)
+}
+
+// errWriter remembers the first write error, so that the formats can be written without checking every write.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) printf(format string, args ...interface{}) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, args...)
+	}
+}
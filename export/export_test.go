@@ -0,0 +1,356 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func date(s string) *neo.Date {
+	d := neo.MustParseDate(s)
+	return &d
+}
+
+func testStatement() *Statement {
+	tx := func(id, amount string, cd neo.CreditDebit, booked string) *neo.Tx {
+		return &neo.Tx{
+			ID:                   id,
+			TransactionAmount:    &neo.Money{Currency: neo.CurrencyNOK, Value: neo.MustParseAmount(amount)},
+			CreditDebitIndicator: cd,
+			Status:               neo.TxBooked,
+			BookingDate:          date(booked),
+			ValueDate:            date(booked),
+		}
+	}
+	salary := tx("tx-1", "32500.00", neo.Credit, "2022-03-01")
+	salary.DebtorName, salary.DebtorAccount = "Arbeidsgiver AS", &neo.AccountInfo{IBAN: "NO8330001234567"}
+	salary.RemittanceInfoUnstructured = "Lonn mars"
+	salary.EndToEndIdentification = "e2e-salary-03"
+	salary.BankTransactionCode = "PMNT-RCDT-SALA"
+
+	coffee := tx("tx-2", "54.90", neo.Debit, "2022-03-02")
+	coffee.CounterpartyName = "Kaffebar Østbanen/Oslo"
+	coffee.ValueDate = date("2022-03-01")
+	coffee.TransactionReference = "REF-2"
+
+	rent := tx("tx-3", "12000", neo.Debit, "2022-03-15")
+	rent.CreditorName, rent.CreditorAccount = "Utleier Eiendom Holding Og Forvaltning AS", &neo.AccountInfo{BBAN: "86011117947"}
+	rent.RemittanceInfoStructured = &neo.RemittanceInfoStructured{Reference: "RF18539007547034", Type: "SCOR"}
+
+	pending := tx("tx-4", "199.00", neo.Debit, "2022-03-31")
+	pending.Status, pending.BookingDate = neo.TxPending, nil
+
+	return &Statement{
+		ID:        "2022-03",
+		CreatedAt: time.Date(2022, time.April, 1, 8, 0, 0, 0, time.UTC),
+		Account: &neo.Account{
+			IBAN:        "NO93 8601 1117 947",
+			AccountName: "Brukskonto",
+			OwnerName:   "Kari Nordmann",
+		},
+		From: neo.NewDate(2022, time.March, 1),
+		To:   neo.NewDate(2022, time.March, 31),
+		Opening: &neo.Balance{
+			Amount:   neo.MustParseAmount("1000.00"),
+			Currency: neo.CurrencyNOK,
+			Type:     neo.BalanceOpeningBooked,
+		},
+		Txs: []*neo.Tx{salary, coffee, rent, pending},
+	}
+}
+
+// summary is what the formats are expected to preserve of a transaction.
+type summary struct {
+	ID, Amount, BookingDate, ValueDate, Name, Account, Remittance, EndToEndID string
+}
+
+func summarize(tx *neo.Tx) summary {
+	name, account := counterparty(tx)
+	s := summary{
+		ID:         tx.ID,
+		Amount:     tx.SignedAmount().Format(neo.CurrencyNOK),
+		Name:       name,
+		Account:    account,
+		Remittance: remittance(tx),
+		EndToEndID: tx.EndToEndIdentification,
+	}
+	if tx.BookingDate != nil {
+		s.BookingDate = tx.BookingDate.String()
+	}
+	if tx.ValueDate != nil {
+		s.ValueDate = tx.ValueDate.String()
+	}
+	return s
+}
+
+func TestFormats(t *testing.T) {
+	for _, test := range []struct {
+		file    string
+		format  Format
+		pending bool                    // Whether the pending transactions are exported.
+		lossy   func(s summary) summary // Adjusts the expectation to what the format holds.
+	}{
+		{
+			file:   "statement.camt053.xml",
+			format: CAMT053{},
+		},
+		{
+			file:   "statement.mt940",
+			format: MT940{},
+			lossy: func(s summary) summary {
+				s.Name = strings.ReplaceAll(swift(s.Name), "/", "-")
+				return s
+			},
+		},
+		{
+			file:   "statement.ofx",
+			format: OFX{},
+			lossy: func(s summary) summary {
+				s.Name, s.Account, s.EndToEndID = truncate(s.Name, ofxNameLen), "", ""
+				return s
+			},
+		},
+		{
+			file:    "statement.csv",
+			format:  &CSV{Columns: append(DefaultCSVColumns, ColumnEndToEndID)},
+			pending: true,
+		},
+	} {
+		test := test
+		t.Run(test.file, func(t *testing.T) {
+			s := testStatement()
+			var buf bytes.Buffer
+			if err := test.format.Export(&buf, s); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", test.file)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Fatalf("the export doesn't match %s:\n%s", golden, buf.String())
+			}
+
+			imported, err := test.format.Import(bytes.NewReader(want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expected, got []summary
+			for _, tx := range s.Txs {
+				if tx.Status == neo.TxPending && !test.pending {
+					continue
+				}
+				e := summarize(tx)
+				if test.lossy != nil {
+					e = test.lossy(e)
+				}
+				expected = append(expected, e)
+			}
+			for _, tx := range imported.Txs {
+				got = append(got, summarize(tx))
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Fatalf("unexpected round-trip:\nexpected %+v\ngot      %+v", expected, got)
+			}
+			if _, ok := test.format.(*CSV); ok {
+				return
+			}
+			if imported.Closing == nil || imported.Closing.Amount.String() != "21445.10" {
+				t.Fatalf("expected the computed closing balance, got %+v", imported.Closing)
+			}
+			if neo.NormalizeIBAN(s.Account.IBAN) != imported.Account.IBAN {
+				t.Fatalf("expected the account IBAN, got %+v", imported.Account)
+			}
+		})
+	}
+}
+
+// balanceOf summarizes the balance as its amount & reference date.
+func balanceOf(b *neo.Balance) string {
+	if b == nil {
+		return ""
+	}
+	s := b.Amount.Format(b.Currency)
+	if b.ReferenceDate != nil {
+		s += " " + b.ReferenceDate.String()
+	}
+	return s
+}
+
+// TestImportBankSamples imports statements laid out like the files of the banks, rather than written by the exporters:
+// bank.camt053.xml follows the statement example of the ISO 20022 message definition report,
+// bank.mt940 the MT940 of a German bank, with the SWIFT envelope & the ?-subfields of the :86: tag,
+// and bank.ofx the OFX 1.0.2 SGML statement example of the OFX specification.
+func TestImportBankSamples(t *testing.T) {
+	for _, test := range []struct {
+		file                      string
+		format                    Format
+		id, account, from, to     string
+		createdAt                 time.Time
+		opening, closing, pending string
+		txs                       []summary
+	}{
+		{
+			file:      "bank.camt053.xml",
+			format:    CAMT053{},
+			id:        "AAAASESS-FP-STAT001",
+			account:   "50000000054910",
+			from:      "2010-10-18",
+			to:        "2010-10-18",
+			createdAt: time.Date(2010, time.October, 18, 16, 0, 0, 0, time.UTC),
+			opening:   "500000.00 2010-10-15",
+			closing:   "435678.50 2010-10-18",
+			pending:   "-250.00",
+			txs: []summary{
+				{"", "-105678.50", "2010-10-18", "2010-10-18", "Fabrikam AB", "SE4550000000058398257466", "RF18539007547034", "ABC/4562/2010-09-08"},
+				{"", "41357.00", "2010-10-18", "2010-10-18", "Contoso Ltd", "GB29NWBK60161331926819", "Invoice 2010-117 Order 4711", ""},
+				{"", "-250.00", "", "2010-10-19", "", "", "", ""},
+			},
+		},
+		{
+			file:    "bank.mt940",
+			format:  MT940{},
+			id:      "STARTUMS",
+			account: "50070010/0123456789",
+			from:    "2022-02-28",
+			to:      "2022-03-02",
+			opening: "12345.67 2022-02-28",
+			closing: "13747.77 2022-03-02",
+			txs: []summary{
+				{"0000000123", "-85.40", "2022-03-01", "2022-03-01", "Stadtwerke Musterstadt", "DE89370400440532013000", "Rechnung 2022-0042 Strom", "RE-2022-0042"},
+				{"0000000124", "1500.00", "2022-03-02", "2022-03-02", "Muster GmbH", "DE02120300000000202051", "Gehalt Maerz 2022", ""},
+				{"0000000125", "-12.50", "2022-03-02", "2022-03-02", "", "", "KONTOFUEHRUNGSENTGELT 02/2022", ""},
+			},
+		},
+		{
+			file:      "bank.ofx",
+			format:    OFX{},
+			id:        "1001",
+			account:   "999988",
+			from:      "2005-10-01",
+			to:        "2005-10-28",
+			createdAt: time.Date(2005, time.October, 29, 10, 10, 3, 0, time.UTC),
+			closing:   "200.29 2005-10-29",
+			txs: []summary{
+				{"00002", "-200.00", "2005-10-04", "", "", "", "", ""},
+				{"00003", "-300.00", "2005-10-20", "2005-10-20", "", "", "", ""},
+			},
+		},
+	} {
+		test := test
+		t.Run(test.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			s, err := test.format.Import(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.ID != test.id || accountNumber(s.Account) != test.account || s.From.String() != test.from || s.To.String() != test.to {
+				t.Fatalf("unexpected statement %s %s %s - %s", s.ID, accountNumber(s.Account), s.From, s.To)
+			}
+			if !s.CreatedAt.Equal(test.createdAt) {
+				t.Fatalf("expected the statement to be created at %s, got %s", test.createdAt, s.CreatedAt)
+			}
+			if balanceOf(s.Opening) != test.opening || balanceOf(s.Closing) != test.closing {
+				t.Fatalf("unexpected balances %q, %q", balanceOf(s.Opening), balanceOf(s.Closing))
+			}
+			var got []summary
+			var pending []string
+			for _, tx := range s.Txs {
+				got = append(got, summarize(tx))
+				if tx.Status == neo.TxPending {
+					pending = append(pending, tx.SignedAmount().Format(tx.TransactionAmount.Currency))
+				}
+			}
+			if !reflect.DeepEqual(test.txs, got) {
+				t.Fatalf("unexpected transactions:\nexpected %+v\ngot      %+v", test.txs, got)
+			}
+			if strings.Join(pending, ",") != test.pending {
+				t.Fatalf("expected %q to be pending, got %v", test.pending, pending)
+			}
+		})
+	}
+}
+
+func TestExportWithoutBalance(t *testing.T) {
+	s := testStatement()
+	s.Opening = nil
+	if err := (MT940{}).Export(&bytes.Buffer{}, s); !errors.Is(err, ErrNoBalance) {
+		t.Fatalf("expected ErrNoBalance, got %v", err)
+	}
+	s.Closing = &neo.Balance{Amount: neo.MustParseAmount("21445.10"), Currency: neo.CurrencyNOK}
+	var buf bytes.Buffer
+	if err := (MT940{}).Export(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), ":60F:C220301NOK1000,00") {
+		t.Fatalf("expected the computed opening balance, got:\n%s", buf.String())
+	}
+}
+
+func TestCSVFormulas(t *testing.T) {
+	s := testStatement()
+	tx := s.Txs[0]
+	tx.CounterpartyName = `=HYPERLINK("http://x")`
+	tx.RemittanceInfoUnstructured = "@SUM(A1:A9)"
+	tx.TransactionReference = "\t+47"
+	c := &CSV{Columns: []Column{ColumnAmount, ColumnCounterpartyName, ColumnRemittance, ColumnReference}}
+
+	var buf bytes.Buffer
+	if err := c.Export(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	row := strings.Split(buf.String(), "\n")[1]
+	if want := `32500.00;"'=HYPERLINK(""http://x"")";'@SUM(A1:A9);'` + "\t+47"; row != want {
+		t.Fatalf("expected the formulas to be escaped, got %q", row)
+	}
+	imported, err := c.Import(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := imported.Txs[0]
+	if got.CounterpartyName != tx.CounterpartyName || got.RemittanceInfoUnstructured != tx.RemittanceInfoUnstructured ||
+		got.TransactionReference != tx.TransactionReference {
+		t.Fatalf("expected the escaping to be undone, got %+v", got)
+	}
+
+	buf.Reset()
+	c.Unescaped = true
+	if err := c.Export(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if row := strings.Split(buf.String(), "\n")[1]; !strings.HasPrefix(row, `32500.00;"=HYPERLINK(`) {
+		t.Fatalf("expected the text as-is, got %q", row)
+	}
+}
+
+func TestOFXParseTime(t *testing.T) {
+	for s, want := range map[string]time.Time{
+		"20051029101003":             time.Date(2005, time.October, 29, 10, 10, 3, 0, time.UTC),
+		"20051029101003.000[-5:EST]": time.Date(2005, time.October, 29, 15, 10, 3, 0, time.UTC),
+		"20220301120000.500[+1:CET]": time.Date(2022, time.March, 1, 11, 0, 0, 5e8, time.UTC),
+	} {
+		if got, err := ofxParseTime(s); err != nil || !got.Equal(want) {
+			t.Fatalf("%s: expected %s, got %s, %v", s, want, got, err)
+		}
+	}
+}
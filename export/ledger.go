@@ -0,0 +1,247 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/enfunc/neo"
+)
+
+var (
+	ErrUnmappedTx       = errors.New("no ledger account for the transaction")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCompany   = errors.New("invalid company")
+)
+
+// Company is the company the accounting files are written for.
+type Company struct {
+	RegistrationNumber string // The organisation number, e.g. 999999999 in Norway or 556677-8899 in Sweden.
+	Name               string
+	Street             string
+	PostalCode         string
+	City               string
+	Country            string // The ISO 3166-1 alpha-2 code.
+	ContactFirstName   string
+	ContactLastName    string
+}
+
+// Software identifies the program that writes the accounting files.
+type Software struct {
+	Company, Name, Version string
+}
+
+// DefaultSoftware is written into the accounting files if no other is given.
+var DefaultSoftware = Software{Company: "enfunc", Name: "neo", Version: "1.0"}
+
+// LedgerAccount is an account of the chart of accounts, e.g. 1920 Bankinnskudd.
+type LedgerAccount struct {
+	ID          string
+	Description string
+	// The standard account of SAF-T the account is grouped under, i.e. the first two digits
+	// of the Norwegian standard chart of accounts. Defaults to the first two digits of the ID.
+	StandardID string
+}
+
+func (a LedgerAccount) standardID() string {
+	if a.StandardID != "" || len(a.ID) < 2 {
+		return a.StandardID
+	}
+	return a.ID[:2]
+}
+
+// LedgerRule posts the transactions it matches against its account.
+// The empty fields match any transaction, so a rule with only an account matches them all.
+type LedgerRule struct {
+	CounterpartyAccount string          // The IBAN or BBAN of the counterparty.
+	CounterpartyName    string          // A case-insensitive part of the counterparty name.
+	Remittance          string          // A case-insensitive part of the remittance info.
+	BankTxCode          string          // A prefix of the bank transaction code, e.g. PMNT-RCDT.
+	CreditDebit         neo.CreditDebit // Whether to match only incoming or outgoing transactions.
+	Account             LedgerAccount
+}
+
+func (r *LedgerRule) match(tx *neo.Tx) bool {
+	name, account := counterparty(tx)
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+	switch {
+	case r.CounterpartyAccount != "" && normalizeNumber(r.CounterpartyAccount) != normalizeNumber(account):
+		return false
+	case r.CounterpartyName != "" && !contains(name, r.CounterpartyName):
+		return false
+	case r.Remittance != "" && !contains(remittance(tx), r.Remittance):
+		return false
+	case r.BankTxCode != "" && !strings.HasPrefix(tx.BankTransactionCode, r.BankTxCode):
+		return false
+	case r.CreditDebit != "" && r.CreditDebit != creditDebit(tx.SignedAmount()):
+		return false
+	}
+	return true
+}
+
+func normalizeNumber(s string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(s))
+}
+
+// LedgerMap maps the bank accounts & the transactions to the accounts of the general ledger.
+//
+// Every transaction is posted twice: against the ledger account of its bank account,
+// and against the account of the first matching rule, or the suspense account if none matches.
+type LedgerMap struct {
+	Bank        map[string]LedgerAccount // Keyed by the IBAN, BBAN or ID of the bank account.
+	DefaultBank LedgerAccount            // The ledger account of the bank accounts missing from Bank.
+	Rules       []LedgerRule
+	Suspense    LedgerAccount // Where the unmatched transactions are posted, if set.
+}
+
+func (m *LedgerMap) bank(acc *neo.Account) (LedgerAccount, bool) {
+	for _, key := range []string{accountNumber(acc), acc.IBAN, acc.BBAN, acc.ID} {
+		if a, ok := m.Bank[key]; ok && key != "" {
+			return a, true
+		}
+	}
+	return m.DefaultBank, m.DefaultBank.ID != ""
+}
+
+func (m *LedgerMap) counter(tx *neo.Tx) (LedgerAccount, bool) {
+	for i := range m.Rules {
+		if m.Rules[i].match(tx) {
+			return m.Rules[i].Account, true
+		}
+	}
+	return m.Suspense, m.Suspense.ID != ""
+}
+
+// posting is a line of a voucher. Debits are positive, credits negative.
+type posting struct {
+	account LedgerAccount
+	amount  neo.Amount
+}
+
+// voucher books a transaction against the bank account & the counter account.
+type voucher struct {
+	no       int
+	date     neo.Date
+	text     string
+	tx       *neo.Tx
+	postings [2]posting
+}
+
+// ledger is the general ledger of the bank transactions of the statements.
+type ledger struct {
+	currency neo.Currency
+	from, to neo.Date
+	accounts []LedgerAccount // Sorted by ID.
+	opening  map[string]neo.Amount
+	closing  map[string]neo.Amount
+	vouchers []*voucher
+}
+
+// book posts the booked transactions of the statements, which must all be in the given currency.
+// The opening balances of the bank accounts are taken from their earliest statements, while the counter
+// accounts open at zero, as only the bank transactions are known.
+func (m *LedgerMap) book(ss []*Statement, currency neo.Currency) (*ledger, error) { //nolint:cyclop
+	l := &ledger{
+		currency: currency,
+		opening:  make(map[string]neo.Amount),
+		closing:  make(map[string]neo.Amount),
+	}
+	accounts := make(map[string]LedgerAccount)
+	add := func(a LedgerAccount) {
+		if _, ok := accounts[a.ID]; !ok {
+			accounts[a.ID] = a
+			l.accounts = append(l.accounts, a)
+		}
+	}
+	// The earliest statement of each bank account, as several may map to the same ledger account.
+	type first struct {
+		from    neo.Date
+		opening neo.Amount
+		account string
+	}
+	firsts := make(map[string]*first)
+	for _, s := range ss {
+		p, err := prepare(s)
+		if err != nil {
+			return nil, err
+		}
+		if p.Currency != currency {
+			return nil, fmt.Errorf("export: %w: the statement of %s is in %s, not %s",
+				ErrCurrencyMismatch, accountNumber(p.Account), p.Currency, currency)
+		}
+		if l.from.IsZero() || p.From.Before(l.from) {
+			l.from = p.From
+		}
+		if l.to.IsZero() || p.To.After(l.to) {
+			l.to = p.To
+		}
+		bank, ok := m.bank(p.Account)
+		if !ok {
+			return nil, fmt.Errorf("export: %w: no ledger account for the bank account %s", ErrUnmappedTx, accountNumber(p.Account))
+		}
+		add(bank)
+		key := accountNumber(p.Account)
+		if key == "" {
+			key = p.Account.ID
+		}
+		if f, ok := firsts[key]; !ok || p.From.Before(f.from) {
+			firsts[key] = &first{from: p.From, opening: p.opening, account: bank.ID}
+		}
+		for _, tx := range p.booked {
+			counter, ok := m.counter(tx)
+			if !ok {
+				return nil, fmt.Errorf("export: %w %s", ErrUnmappedTx, tx.ID)
+			}
+			add(counter)
+			signed := tx.SignedAmount()
+			l.vouchers = append(l.vouchers, &voucher{
+				date: voucherDate(tx, p.To),
+				text: voucherText(tx),
+				tx:   tx,
+				postings: [2]posting{
+					{account: bank, amount: signed},
+					{account: counter, amount: signed.Neg()},
+				},
+			})
+		}
+	}
+	for _, f := range firsts {
+		l.opening[f.account] = l.opening[f.account].Add(f.opening)
+	}
+	sort.SliceStable(l.vouchers, func(i, j int) bool { return l.vouchers[i].date.Before(l.vouchers[j].date) })
+	for i, v := range l.vouchers {
+		v.no = i + 1
+	}
+	sort.Slice(l.accounts, func(i, j int) bool { return l.accounts[i].ID < l.accounts[j].ID })
+	for id, a := range l.opening {
+		l.closing[id] = a
+	}
+	for _, v := range l.vouchers {
+		for _, p := range v.postings {
+			l.closing[p.account.ID] = l.closing[p.account.ID].Add(p.amount)
+		}
+	}
+	return l, nil
+}
+
+// voucherDate returns the booking date of the transaction, or its value date, or the end of the statement.
+func voucherDate(tx *neo.Tx, end neo.Date) neo.Date {
+	for _, d := range []*neo.Date{tx.BookingDate, tx.ValueDate} {
+		if d != nil && !d.IsZero() {
+			return *d
+		}
+	}
+	return end
+}
+
+// voucherText returns the remittance info of the transaction, or the name of the counterparty.
+func voucherText(tx *neo.Tx) string {
+	if r := remittance(tx); r != "" {
+		return r
+	}
+	name, _ := counterparty(tx)
+	return name
+}
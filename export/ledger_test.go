@@ -0,0 +1,325 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+func testLedger() LedgerMap {
+	return LedgerMap{
+		Rules: []LedgerRule{
+			{CounterpartyAccount: "NO83 3000 1234 567", CreditDebit: neo.Credit, Account: LedgerAccount{ID: "3000", Description: "Salgsinntekt"}},
+			{Remittance: "RF18", Account: LedgerAccount{ID: "6300", Description: "Leie lokale"}},
+			{CounterpartyName: "kaffebar", Account: LedgerAccount{ID: "7140", Description: "Reisekostnad"}},
+		},
+		Suspense: LedgerAccount{ID: "2990", Description: "Annen kortsiktig gjeld"},
+	}
+}
+
+func TestLedgerFormats(t *testing.T) {
+	company := Company{
+		RegistrationNumber: "999999999",
+		Name:               "Nordmann Rør AS",
+		City:               "Oslo",
+		PostalCode:         "0150",
+		Country:            "NO",
+	}
+	created := time.Date(2022, time.April, 1, 8, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		file   string
+		export func(s *Statement) ([]byte, error)
+	}{
+		{
+			file: "ledger.saft.xml",
+			export: func(s *Statement) ([]byte, error) {
+				var buf bytes.Buffer
+				f := &SAFT{Company: company, Ledger: testLedger(), CreatedAt: created}
+				err := f.Export(&buf, s)
+				return buf.Bytes(), err
+			},
+		},
+		{
+			file: "ledger.se",
+			export: func(s *Statement) ([]byte, error) {
+				var buf bytes.Buffer
+				f := &SIE{Company: company, Ledger: testLedger(), Currency: neo.CurrencyNOK, CreatedAt: created}
+				err := f.Export(&buf, s)
+				return buf.Bytes(), err
+			},
+		},
+	} {
+		test := test
+		t.Run(test.file, func(t *testing.T) {
+			b, err := test.export(testStatement())
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", test.file)
+			if *update {
+				if err := os.WriteFile(golden, b, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, want) {
+				t.Fatalf("the export doesn't match %s:\n%s", golden, b)
+			}
+		})
+	}
+}
+
+// saftSchema holds the sequences of the SAF-T Financial 1.30 XSD for the elements the export writes:
+// the child elements of each complex element, in order. Required elements end with "!",
+// and the alternatives of a choice are separated by "|".
+var saftSchema = map[string][]string{
+	"AuditFile": {"Header!", "MasterFiles", "GeneralLedgerEntries", "SourceDocuments"},
+	"Header": {
+		"AuditFileVersion!", "AuditFileCountry!", "AuditFileRegion", "AuditFileDateCreated!",
+		"SoftwareCompanyName!", "SoftwareID!", "SoftwareVersion!", "Company!", "DefaultCurrencyCode!",
+		"SelectionCriteria!", "HeaderComment", "SegmentIndex", "TotalSegments", "UserID",
+		"TaxAccountingBasis!", "TaxEntity",
+	},
+	"Company": {"RegistrationNumber!", "Name!", "Address!", "Contact!", "TaxRegistration", "BankAccount"},
+	"Address": {
+		"StreetName", "Number", "AdditionalAddressDetail", "Building", "City!", "PostalCode!",
+		"Region", "Country", "AddressType",
+	},
+	"Contact":       {"ContactPerson!", "Telephone", "Fax", "Email", "Website", "MobilePhone"},
+	"ContactPerson": {"Title", "FirstName!", "Initials", "LastNamePrefix", "LastName!", "BirthName", "Salutation", "OtherTitles"},
+	"BankAccount": {
+		"IBANNumber|BankAccountNumber!", "BankAccountName", "SortCode", "BIC", "CurrencyCode",
+		"GeneralLedgerAccountID",
+	},
+	"SelectionCriteria": {
+		"TaxReportingJurisdiction", "CompanyEntity", "SelectionStartDate", "SelectionEndDate",
+		"PeriodStart", "PeriodStartYear", "PeriodEnd", "PeriodEndYear", "DocumentType", "OtherCriteria",
+	},
+	"MasterFiles":           {"GeneralLedgerAccounts", "Customers", "Suppliers", "TaxTable", "UOMTable", "AnalysisTypeTable", "Owners"},
+	"GeneralLedgerAccounts": {"Account!"},
+	"Account": {
+		"AccountID!", "AccountDescription!", "StandardAccountID", "GroupingCategory", "GroupingCode",
+		"AccountType!", "AccountCreationDate", "OpeningDebitBalance|OpeningCreditBalance!",
+		"ClosingDebitBalance|ClosingCreditBalance!",
+	},
+	"GeneralLedgerEntries": {"NumberOfEntries!", "TotalDebit!", "TotalCredit!", "Journal"},
+	"Journal":              {"JournalID!", "Description!", "Type!", "Transaction"},
+	"Transaction": {
+		"TransactionID!", "Period!", "PeriodYear!", "TransactionDate!", "SourceID", "TransactionType",
+		"Description!", "BatchID", "SystemEntryDate!", "GLPostingDate!", "CustomerID", "SupplierID",
+		"SystemID", "Line!",
+	},
+	"Line": {
+		"RecordID!", "AccountID!", "Analysis", "ValueDate", "SourceDocumentID", "CustomerID", "SupplierID",
+		"Description!", "DebitAmount|CreditAmount!", "TaxInformation", "ReferenceNumber", "CID", "DueDate",
+		"Quantity", "CrossReference", "SystemEntryTime", "OwnerID",
+	},
+	"DebitAmount":  {"Amount!", "CurrencyCode", "CurrencyAmount", "ExchangeRate"},
+	"CreditAmount": {"Amount!", "CurrencyCode", "CurrencyAmount", "ExchangeRate"},
+}
+
+type xmlNode struct {
+	name     string
+	children []*xmlNode
+}
+
+func parseXML(r io.Reader) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return root.children[0], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: tok.Name.Local}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// checkSAFT checks the order & the presence of the child elements of n against saftSchema.
+func checkSAFT(n *xmlNode) error {
+	seq, ok := saftSchema[n.name]
+	if !ok {
+		if len(n.children) > 0 {
+			return fmt.Errorf("%s: unexpected child elements", n.name)
+		}
+		return nil
+	}
+	seen := make([]string, len(seq))
+	i := 0
+	for _, c := range n.children {
+		j := i
+		for ; j < len(seq); j++ {
+			if alternatives := strings.Split(strings.TrimSuffix(seq[j], "!"), "|"); contains(alternatives, c.name) {
+				break
+			}
+		}
+		if j == len(seq) {
+			return fmt.Errorf("%s: unexpected or misplaced %s", n.name, c.name)
+		}
+		if seen[j] != "" && seen[j] != c.name {
+			return fmt.Errorf("%s: both %s & %s", n.name, seen[j], c.name)
+		}
+		i, seen[j] = j, c.name
+		if err := checkSAFT(c); err != nil {
+			return fmt.Errorf("%s>%w", n.name, err)
+		}
+	}
+	for j, e := range seq {
+		if strings.HasSuffix(e, "!") && seen[j] == "" {
+			return fmt.Errorf("%s: missing %s", n.name, strings.TrimSuffix(e, "!"))
+		}
+	}
+	return nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSAFTSchema(t *testing.T) {
+	s := testStatement()
+	s.Txs = append(s.Txs, &neo.Tx{
+		ID:                   "tx-5",
+		TransactionAmount:    &neo.Money{Currency: neo.CurrencyNOK, Value: neo.MustParseAmount("10.00")},
+		CreditDebitIndicator: neo.Debit,
+		Status:               neo.TxBooked,
+		CounterpartyName:     "Ukjent",
+	})
+	other := testStatement()
+	other.Account = &neo.Account{BBAN: "1206.12.34567"}
+	f := &SAFT{
+		Company: Company{
+			RegistrationNumber: "999999999",
+			Name:               "Nordmann Rør AS",
+			Street:             "Storgata 1",
+			ContactFirstName:   "Kari",
+			ContactLastName:    "Nordmann",
+		},
+		Ledger: testLedger(),
+		// Still March 31 in UTC.
+		CreatedAt: time.Date(2022, time.April, 1, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	}
+	var buf bytes.Buffer
+	if err := f.Export(&buf, s, other); err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseXML(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSAFT(root); err != nil {
+		t.Fatalf("the export doesn't match the SAF-T schema: %v", err)
+	}
+	for _, e := range []string{"AuditFileDateCreated", "SystemEntryDate"} {
+		if !strings.Contains(buf.String(), "<"+e+">2022-03-31</"+e+">") {
+			t.Fatalf("expected the %s in UTC:\n%s", e, buf.String())
+		}
+	}
+
+	golden, err := os.Open(filepath.Join("testdata", "ledger.saft.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer golden.Close()
+	if root, err = parseXML(golden); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSAFT(root); err != nil {
+		t.Fatalf("ledger.saft.xml doesn't match the SAF-T schema: %v", err)
+	}
+}
+
+func TestLedgerBook(t *testing.T) {
+	m := testLedger()
+	m.DefaultBank = LedgerAccount{ID: "1920"}
+	l, err := m.book([]*Statement{testStatement()}, neo.CurrencyNOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, v := range l.vouchers {
+		ids = append(ids, v.postings[1].account.ID)
+	}
+	if len(ids) != 3 || ids[0] != "3000" || ids[1] != "7140" || ids[2] != "6300" {
+		t.Fatalf("unexpected counter accounts: %v", ids)
+	}
+	for id, want := range map[string]string{"1920": "21445.10", "3000": "-32500.00", "6300": "12000.00", "7140": "54.90"} {
+		if got := l.closing[id].Format(neo.CurrencyNOK); got != want {
+			t.Fatalf("expected the closing balance %s of %s, got %s", want, id, got)
+		}
+	}
+
+	// Consecutive statements of the same bank account, given out of order.
+	month := func(m time.Month, opening string) *Statement {
+		booked := neo.NewDate(2022, m, 10)
+		return &Statement{
+			Account: &neo.Account{IBAN: "NO9386011117947"},
+			From:    neo.NewDate(2022, m, 1),
+			To:      neo.NewDate(2022, m+1, 0),
+			Opening: &neo.Balance{Amount: neo.MustParseAmount(opening), Currency: neo.CurrencyNOK},
+			Txs: []*neo.Tx{{
+				ID:                   "tx-" + m.String(),
+				TransactionAmount:    &neo.Money{Currency: neo.CurrencyNOK, Value: neo.MustParseAmount("100.00")},
+				CreditDebitIndicator: neo.Credit,
+				Status:               neo.TxBooked,
+				BookingDate:          &booked,
+			}},
+		}
+	}
+	l, err = m.book([]*Statement{month(time.February, "1100.00"), month(time.January, "1000.00")}, neo.CurrencyNOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.opening["1920"].Format(neo.CurrencyNOK); got != "1000.00" {
+		t.Fatalf("expected the opening balance of the earliest statement, got %s", got)
+	}
+	if got := l.closing["1920"].Format(neo.CurrencyNOK); got != "1200.00" {
+		t.Fatalf("expected the closing balance 1200.00, got %s", got)
+	}
+
+	m.Suspense = LedgerAccount{}
+	m.Rules = m.Rules[:1]
+	if _, err := m.book([]*Statement{testStatement()}, neo.CurrencyNOK); !errors.Is(err, ErrUnmappedTx) {
+		t.Fatalf("expected ErrUnmappedTx, got %v", err)
+	}
+	if _, err := m.book([]*Statement{testStatement()}, neo.CurrencySEK); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("expected ErrCurrencyMismatch, got %v", err)
+	}
+}
+
+func TestCP437(t *testing.T) {
+	got := cp437("Företagskonto Østbanen €")
+	want := []byte("F\x94retagskonto \x99stbanen ?")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
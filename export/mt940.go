@@ -0,0 +1,392 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+// MT940 is the SWIFT customer statement message, in the plain text form used for file imports,
+// i.e. the tags of block 4 without the SWIFT envelope.
+//
+// The counterparty & the remittance info are written into the :86: tag as /NAME/, /ACCT/
+// and /REMI/ subfields. The texts are transliterated to the SWIFT character set.
+// Import also accepts the SWIFT envelope, and the :86: tag as the ?-subfields of the German banks or as free text.
+type MT940 struct{}
+
+const (
+	mt940Date     = "060102"
+	mt940LineLen  = 65
+	mt940MaxLines = 6
+)
+
+func (MT940) Export(w io.Writer, s *Statement) error {
+	p, err := prepare(s)
+	if err != nil {
+		return err
+	}
+	e := &errWriter{w: w}
+	e.printf(":20:%s\r\n", truncate(swift(p.ID), 16))
+	e.printf(":25:%s\r\n", accountNumber(p.Account))
+	e.printf(":28C:%s\r\n", "00001")
+	e.printf(":60F:%s\r\n", mt940Balance(p.opening, p.Currency, p.From))
+	for _, tx := range p.booked {
+		e.printf(":61:%s\r\n", mt940Line(tx, p.Currency, p.To))
+		if info := mt940Info(tx); info != "" {
+			e.printf(":86:%s\r\n", info)
+		}
+	}
+	e.printf(":62F:%s\r\n", mt940Balance(p.closing, p.Currency, p.To))
+	e.printf("-\r\n")
+	if e.err != nil {
+		return fmt.Errorf("export: failed to write MT940: %w", e.err)
+	}
+	return nil
+}
+
+func mt940Balance(a neo.Amount, currency neo.Currency, d neo.Date) string {
+	return fmt.Sprintf("%s%s%s%s", mt940CreditDebit(a), d.In(time.UTC).Format(mt940Date), currency, mt940Amount(a, currency))
+}
+
+func mt940CreditDebit(a neo.Amount) string {
+	if a.Sign() < 0 {
+		return "D"
+	}
+	return "C"
+}
+
+// mt940Amount formats the absolute amount with a decimal comma, which is mandatory even without decimals.
+func mt940Amount(a neo.Amount, currency neo.Currency) string {
+	s := a.Abs().Format(currency)
+	if !strings.Contains(s, ".") {
+		return s + ","
+	}
+	return strings.Replace(s, ".", ",", 1)
+}
+
+// mt940Line formats the :61: statement line: value date, entry date, debit/credit mark, amount,
+// transaction type, reference for the account owner & the reference of the bank.
+// Missing dates default to each other, or to the end of the statement.
+func mt940Line(tx *neo.Tx, currency neo.Currency, end neo.Date) string {
+	booking, value := end, end
+	switch {
+	case tx.BookingDate != nil && !tx.BookingDate.IsZero():
+		booking, value = *tx.BookingDate, *tx.BookingDate
+	case tx.ValueDate != nil && !tx.ValueDate.IsZero():
+		booking = *tx.ValueDate
+	}
+	if tx.ValueDate != nil && !tx.ValueDate.IsZero() {
+		value = *tx.ValueDate
+	}
+	signed := tx.SignedAmount()
+	ref := truncate(swift(tx.EndToEndIdentification), 16)
+	if ref == "" {
+		ref = "NONREF"
+	}
+	line := fmt.Sprintf("%s%s%s%sNTRF%s",
+		value.In(time.UTC).Format(mt940Date),
+		booking.In(time.UTC).Format("0102"),
+		mt940CreditDebit(signed),
+		mt940Amount(signed, currency),
+		ref,
+	)
+	if tx.ID != "" {
+		line += "//" + truncate(swift(tx.ID), 16)
+	}
+	return line
+}
+
+// mt940Info formats the :86: information to the account owner, wrapped into lines of up to 65 characters.
+// As lines starting with ":" or "-" would be mistaken for a tag or the end of the message, they're wrapped earlier.
+func mt940Info(tx *neo.Tx) string {
+	var b strings.Builder
+	name, account := counterparty(tx)
+	for _, f := range []struct{ code, value string }{
+		{"NAME", name},
+		{"ACCT", account},
+		{"REMI", remittance(tx)},
+	} {
+		if v := strings.ReplaceAll(swift(f.value), "/", "-"); v != "" {
+			b.WriteString("/" + f.code + "/" + v)
+		}
+	}
+	info := truncate(b.String(), mt940LineLen*mt940MaxLines)
+	var lines []string
+	for len(info) > mt940LineLen {
+		n := mt940LineLen
+		for n > 1 && (info[n] == ':' || info[n] == '-') {
+			n--
+		}
+		lines, info = append(lines, info[:n]), info[n:]
+	}
+	return strings.Join(append(lines, info), "\r\n")
+}
+
+func (MT940) Import(r io.Reader) (*Statement, error) { //nolint:cyclop
+	s := &Statement{Account: &neo.Account{}}
+	var tag, value string
+	var last *neo.Tx
+	flush := func() error {
+		defer func() { tag, value = "", "" }()
+		switch tag {
+		case "20":
+			s.ID = value
+		case "25":
+			setAccountNumber(s.Account, value)
+		case "60F", "60M":
+			b, err := mt940ParseBalance(value, neo.BalanceOpeningBooked)
+			if err != nil {
+				return err
+			}
+			s.Opening, s.Currency, s.From = b, b.Currency, *b.ReferenceDate
+		case "62F", "62M":
+			b, err := mt940ParseBalance(value, neo.BalanceClosingBooked)
+			if err != nil {
+				return err
+			}
+			s.Closing, s.To = b, *b.ReferenceDate
+		case "61":
+			tx, err := mt940ParseLine(value, s.Currency)
+			if err != nil {
+				return err
+			}
+			s.Txs, last = append(s.Txs, tx), tx
+		case "86":
+			if last != nil {
+				mt940ParseInfo(last, value)
+			}
+		}
+		return nil
+	}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		// The SWIFT envelope: the header blocks precede the tags of block 4, which ends with "-}".
+		if _, body, ok := strings.Cut(line, "{4:"); ok {
+			if line = body; line == "" {
+				continue
+			}
+		}
+		if t, v, ok := mt940Tag(line); ok || line == "-" || line == "-}" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			tag, value = t, v
+			continue
+		}
+		// A continuation of the previous tag. The lines of :86: are wrapped at a fixed length, so they're joined as-is.
+		if tag == "86" {
+			value += line
+		} else {
+			value += "\n" + line
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("export: failed to read MT940: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if s.Opening == nil {
+		return nil, fmt.Errorf("export: %w: MT940 without an opening balance", ErrInvalidFormat)
+	}
+	return s, nil
+}
+
+func mt940Tag(line string) (tag, value string, ok bool) {
+	if !strings.HasPrefix(line, ":") {
+		return "", "", false
+	}
+	tag, value, ok = strings.Cut(line[1:], ":")
+	if !ok || len(tag) < 2 || len(tag) > 3 {
+		return "", "", false
+	}
+	return tag, value, true
+}
+
+func mt940ParseBalance(v string, t neo.BalanceType) (*neo.Balance, error) {
+	if len(v) < 11 {
+		return nil, fmt.Errorf("export: %w: MT940 balance %q", ErrInvalidFormat, v)
+	}
+	d, err := time.Parse(mt940Date, v[1:7])
+	if err != nil {
+		return nil, fmt.Errorf("export: %w: MT940 balance date %q", ErrInvalidFormat, v)
+	}
+	a, err := mt940ParseAmount(v[10:], v[:1])
+	if err != nil {
+		return nil, err
+	}
+	date := neo.DateOf(d)
+	return &neo.Balance{Amount: a, Currency: neo.Currency(v[7:10]), Type: t, ReferenceDate: &date}, nil
+}
+
+func mt940ParseAmount(v, mark string) (neo.Amount, error) {
+	a, err := neo.ParseAmount(strings.TrimSuffix(strings.Replace(v, ",", ".", 1), "."))
+	if err != nil {
+		return neo.Amount{}, fmt.Errorf("export: %w: MT940 amount %q", ErrInvalidFormat, v)
+	}
+	if mark == "D" || mark == "RC" {
+		return a.Neg(), nil
+	}
+	return a, nil
+}
+
+func mt940ParseLine(v string, currency neo.Currency) (*neo.Tx, error) {
+	invalid := fmt.Errorf("export: %w: MT940 statement line %q", ErrInvalidFormat, v)
+	if len(v) < 12 {
+		return nil, invalid
+	}
+	vd, err := time.Parse(mt940Date, v[:6])
+	if err != nil {
+		return nil, invalid
+	}
+	value := neo.DateOf(vd)
+	booking, rest := value, v[6:]
+	if len(rest) >= 4 && isDigits(rest[:4]) {
+		bd, err := time.Parse("20060102", fmt.Sprintf("%04d%s", value.Year(), rest[:4]))
+		if err != nil {
+			return nil, invalid
+		}
+		// An entry date in January for a value date in December belongs to the next year, and vice versa.
+		booking, rest = neo.DateOf(bd), rest[4:]
+		switch {
+		case value.Month() == time.December && booking.Month() == time.January:
+			booking = neo.NewDate(booking.Year()+1, booking.Month(), booking.Day())
+		case value.Month() == time.January && booking.Month() == time.December:
+			booking = neo.NewDate(booking.Year()-1, booking.Month(), booking.Day())
+		}
+	}
+	mark := rest[:1]
+	if strings.HasPrefix(rest, "RC") || strings.HasPrefix(rest, "RD") {
+		mark = rest[:2]
+	}
+	rest = rest[len(mark):]
+	if rest != "" && rest[0] >= 'A' && rest[0] <= 'Z' {
+		rest = rest[1:] // The funds code.
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool { return r != ',' && (r < '0' || r > '9') })
+	if end < 0 || len(rest) < end+4 {
+		return nil, invalid
+	}
+	a, err := mt940ParseAmount(rest[:end], mark)
+	if err != nil {
+		return nil, err
+	}
+	tx := newTx(a, currency)
+	tx.BookingDate, tx.ValueDate = datePtr(booking), datePtr(value)
+	ref, bankRef, _ := strings.Cut(rest[end+4:], "//")
+	if ref != "NONREF" {
+		tx.EndToEndIdentification = ref
+	}
+	tx.ID, _, _ = strings.Cut(bankRef, "\n")
+	return tx, nil
+}
+
+// mt940ParseInfo parses the :86: tag, structured as /NAME/, /ACCT/ & /REMI/ subfields, as the ?-subfields
+// following the 3-digit transaction code, or as free text taken as the remittance info.
+func mt940ParseInfo(tx *neo.Tx, v string) {
+	if len(v) > 4 && isDigits(v[:3]) && v[3] == '?' {
+		mt940ParseSubfields(tx, v[3:])
+		return
+	}
+	structured := false
+	fields := strings.Split(v, "/")
+	for i := 1; i+1 < len(fields); i += 2 {
+		switch value := fields[i+1]; fields[i] {
+		case "NAME":
+			tx.CounterpartyName, structured = value, true
+		case "ACCT":
+			tx.CounterpartyAccount, structured = value, true
+		case "REMI":
+			tx.RemittanceInfoUnstructured, structured = value, true
+		}
+	}
+	if !structured {
+		tx.RemittanceInfoUnstructured = strings.TrimSpace(v)
+	}
+}
+
+// mt940ParseSubfields parses the ?-subfields of the German banks: ?20-?29 & ?60-?63 hold the purpose,
+// ?31 the account & ?32-?33 the name of the counterparty. A SEPA purpose is split further into its keyed fields.
+func mt940ParseSubfields(tx *neo.Tx, v string) {
+	var purpose, name strings.Builder
+	for _, f := range strings.Split(v, "?") {
+		if len(f) < 2 {
+			continue
+		}
+		switch code, value := f[:2], f[2:]; {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			purpose.WriteString(value)
+		case code == "31":
+			tx.CounterpartyAccount = value
+		case code == "32", code == "33":
+			name.WriteString(value)
+		}
+	}
+	tx.CounterpartyName = strings.TrimSpace(name.String())
+	tx.RemittanceInfoUnstructured = strings.TrimSpace(purpose.String())
+	fields := sepaFields(purpose.String())
+	if svwz, ok := fields["SVWZ"]; ok {
+		tx.RemittanceInfoUnstructured = svwz
+	}
+	if eref := fields["EREF"]; eref != "" && eref != notProvided && tx.EndToEndIdentification == "" {
+		tx.EndToEndIdentification = eref
+	}
+}
+
+// sepaKeys prefix the fields of a SEPA purpose, e.g. EREF+ the end-to-end ID & SVWZ+ the remittance info.
+var sepaKeys = []string{"EREF+", "KREF+", "MREF+", "CRED+", "DEBT+", "COAM+", "OAMT+", "SVWZ+", "ABWA+", "ABWE+"}
+
+// sepaFields splits the SEPA purpose into its fields by key, without the trailing "+".
+func sepaFields(s string) map[string]string {
+	var at []int
+	for _, k := range sepaKeys {
+		if i := strings.Index(s, k); i >= 0 {
+			at = append(at, i)
+		}
+	}
+	sort.Ints(at)
+	fields := make(map[string]string, len(at))
+	for j, i := range at {
+		end := len(s)
+		if j+1 < len(at) {
+			end = at[j+1]
+		}
+		fields[s[i:i+4]] = strings.TrimSpace(s[i+5 : end])
+	}
+	return fields
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// swiftTransliterations maps the common letters outside the SWIFT character set.
+var swiftTransliterations = strings.NewReplacer(
+	"Æ", "AE", "æ", "ae", "Ø", "OE", "ø", "oe", "Å", "AA", "å", "aa",
+	"Ä", "AE", "ä", "ae", "Ö", "OE", "ö", "oe", "Ü", "UE", "ü", "ue", "ß", "ss",
+	"É", "E", "é", "e", "È", "E", "è", "e",
+)
+
+// swift converts the text to the SWIFT X character set, replacing the unsupported characters with ".".
+func swift(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-?:().,'+ ", r):
+			return r
+		}
+		return '.'
+	}, swiftTransliterations.Replace(strings.TrimSpace(s)))
+}
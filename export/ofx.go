@@ -0,0 +1,266 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+// OFX is the Open Financial Exchange 2.2 bank statement response.
+//
+// The bank ID is taken from the bank code of the IBAN, if any. Names are limited to 32 characters,
+// and the remittance info is written into the memo. Import also accepts the SGML of OFX 1.x.
+type OFX struct {
+	AccountType string // The OFX account type, e.g. SAVINGS. Defaults to CHECKING.
+}
+
+const (
+	ofxHeader   = `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxDate     = "20060102"
+	ofxDateTime = "20060102150405"
+	ofxNameLen  = 32
+)
+
+type ofxDocument struct {
+	XMLName  xml.Name  `xml:"OFX"`
+	Status   ofxStatus `xml:"SIGNONMSGSRSV1>SONRS>STATUS"`
+	DTServer string    `xml:"SIGNONMSGSRSV1>SONRS>DTSERVER"`
+	Language string    `xml:"SIGNONMSGSRSV1>SONRS>LANGUAGE"`
+	TrnUID   string    `xml:"BANKMSGSRSV1>STMTTRNRS>TRNUID"`
+	TrStatus ofxStatus `xml:"BANKMSGSRSV1>STMTTRNRS>STATUS"`
+	Stmt     ofxStmt   `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxStmt struct {
+	CurDef   string     `xml:"CURDEF"`
+	BankID   string     `xml:"BANKACCTFROM>BANKID"`
+	AcctID   string     `xml:"BANKACCTFROM>ACCTID"`
+	AcctType string     `xml:"BANKACCTFROM>ACCTTYPE"`
+	DTStart  string     `xml:"BANKTRANLIST>DTSTART"`
+	DTEnd    string     `xml:"BANKTRANLIST>DTEND"`
+	Txs      []ofxTx    `xml:"BANKTRANLIST>STMTTRN"`
+	Ledger   ofxBalance `xml:"LEDGERBAL"`
+}
+
+type ofxTx struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	DTUser   string `xml:"DTUSER,omitempty"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FitID    string `xml:"FITID"`
+	RefNum   string `xml:"REFNUM,omitempty"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+func (o OFX) Export(w io.Writer, s *Statement) error {
+	p, err := prepare(s)
+	if err != nil {
+		return err
+	}
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+	doc := &ofxDocument{
+		Status:   ok,
+		DTServer: p.CreatedAt.UTC().Format(ofxDateTime),
+		Language: "ENG",
+		TrnUID:   p.ID,
+		TrStatus: ok,
+		Stmt: ofxStmt{
+			CurDef:   string(p.Currency),
+			BankID:   ofxBankID(p.Account),
+			AcctID:   accountNumber(p.Account),
+			AcctType: o.AccountType,
+			DTStart:  ofxDateOf(p.From),
+			DTEnd:    ofxDateOf(p.To),
+			Ledger:   ofxBalance{BalAmt: p.closing.Format(p.Currency), DTAsOf: ofxDateOf(p.To)},
+		},
+	}
+	if doc.Stmt.AcctType == "" {
+		doc.Stmt.AcctType = "CHECKING"
+	}
+	for _, tx := range p.booked {
+		doc.Stmt.Txs = append(doc.Stmt.Txs, ofxTxOf(tx, p.Currency))
+	}
+	if _, err := io.WriteString(w, xml.Header+ofxHeader); err != nil {
+		return fmt.Errorf("export: failed to write OFX: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("export: failed to write OFX: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("export: failed to write OFX: %w", err)
+	}
+	return nil
+}
+
+func (OFX) Import(r io.Reader) (*Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("export: failed to read OFX: %w", err)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("OFXHEADER:")) {
+		dec = xml.NewDecoder(bytes.NewReader(ofxSGML(data)))
+		dec.Strict = false // SGML doesn't escape "&".
+	}
+	doc := &ofxDocument{}
+	if err := dec.Decode(doc); err != nil {
+		return nil, fmt.Errorf("export: %w: OFX: %v", ErrInvalidFormat, err) //nolint:errorlint
+	}
+	st := &doc.Stmt
+	currency := neo.Currency(st.CurDef)
+	s := &Statement{
+		ID:       doc.TrnUID,
+		Account:  &neo.Account{},
+		Currency: currency,
+		From:     ofxParseDate(st.DTStart),
+		To:       ofxParseDate(st.DTEnd),
+	}
+	if t, err := ofxParseTime(doc.DTServer); err == nil {
+		s.CreatedAt = t
+	}
+	setAccountNumber(s.Account, st.AcctID)
+	closing, err := neo.ParseAmount(st.Ledger.BalAmt)
+	if err != nil {
+		return nil, fmt.Errorf("export: %w: OFX balance %q", ErrInvalidFormat, st.Ledger.BalAmt)
+	}
+	s.Closing = &neo.Balance{
+		Amount:        closing,
+		Currency:      currency,
+		Type:          neo.BalanceClosingBooked,
+		ReferenceDate: datePtr(ofxParseDate(st.Ledger.DTAsOf)),
+	}
+	for _, t := range st.Txs {
+		a, err := neo.ParseAmount(t.TrnAmt)
+		if err != nil {
+			return nil, fmt.Errorf("export: %w: OFX amount %q", ErrInvalidFormat, t.TrnAmt)
+		}
+		tx := newTx(a, currency)
+		tx.ID, tx.TransactionReference = t.FitID, t.RefNum
+		tx.BookingDate, tx.ValueDate = datePtr(ofxParseDate(t.DTPosted)), datePtr(ofxParseDate(t.DTUser))
+		tx.CounterpartyName, tx.RemittanceInfoUnstructured = t.Name, t.Memo
+		s.Txs = append(s.Txs, tx)
+	}
+	return s, nil
+}
+
+// ofxBankID returns the bank code contained in the IBAN of the account.
+func ofxBankID(acc *neo.Account) string {
+	iban := neo.NormalizeIBAN(acc.IBAN)
+	if neo.ValidateIBAN(iban) != nil {
+		return ""
+	}
+	return iban[4:8]
+}
+
+func ofxTxOf(tx *neo.Tx, currency neo.Currency) ofxTx {
+	signed := tx.SignedAmount()
+	if tx.TransactionAmount != nil && tx.TransactionAmount.Currency != "" {
+		currency = tx.TransactionAmount.Currency
+	}
+	t := ofxTx{
+		TrnType: "CREDIT",
+		TrnAmt:  signed.Format(currency),
+		FitID:   tx.ID,
+		RefNum:  tx.TransactionReference,
+		Memo:    remittance(tx),
+	}
+	if signed.Sign() < 0 {
+		t.TrnType = "DEBIT"
+	}
+	if tx.BookingDate != nil {
+		t.DTPosted = ofxDateOf(*tx.BookingDate)
+	}
+	if tx.ValueDate != nil {
+		t.DTUser = ofxDateOf(*tx.ValueDate)
+	}
+	if t.FitID == "" {
+		t.FitID = tx.Hash()
+	}
+	name, _ := counterparty(tx)
+	t.Name = truncate(name, ofxNameLen)
+	return t
+}
+
+func ofxDateOf(d neo.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.In(time.UTC).Format(ofxDate)
+}
+
+// ofxSGML converts OFX 1.x SGML into XML, dropping the header & closing the elements holding a value.
+func ofxSGML(data []byte) []byte {
+	if i := bytes.IndexByte(data, '<'); i >= 0 {
+		data = data[i:]
+	}
+	var b bytes.Buffer
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '>')
+		if end < 0 {
+			b.Write(data)
+			break
+		}
+		tag, rest := data[1:end], data[end+1:]
+		next := bytes.IndexByte(rest, '<')
+		if next < 0 {
+			next = len(rest)
+		}
+		text := bytes.TrimSpace(rest[:next])
+		data = rest[next:]
+		b.WriteString("<" + string(tag) + ">")
+		b.Write(text)
+		closing := "</" + string(tag) + ">"
+		if len(text) > 0 && tag[0] != '/' && !bytes.HasPrefix(data, []byte(closing)) {
+			b.WriteString(closing)
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// ofxParseTime parses an OFX timestamp, e.g. 20220301120000.000[+1:CET], which is in UTC unless an offset is given.
+func ofxParseTime(s string) (time.Time, error) {
+	s, zone, _ := strings.Cut(s, "[")
+	t, err := time.Parse(ofxDateTime+".999", s)
+	if err != nil || zone == "" {
+		return t, err
+	}
+	offset, name, _ := strings.Cut(strings.TrimSuffix(zone, "]"), ":")
+	hours, err := strconv.ParseFloat(offset, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("export: %w: OFX time zone %q", ErrInvalidFormat, zone)
+	}
+	loc := time.FixedZone(name, int(hours*3600))
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+}
+
+// ofxParseDate parses an OFX date, ignoring the time & the time zone, e.g. 20220301120000.000[+1:CET].
+func ofxParseDate(s string) neo.Date {
+	if len(s) < 8 {
+		return neo.Date{}
+	}
+	t, err := time.Parse(ofxDate, s[:8])
+	if err != nil {
+		return neo.Date{}
+	}
+	return neo.DateOf(t)
+}
@@ -0,0 +1,288 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+const (
+	saftNamespace = "urn:StandardAuditFile-Taxation-Financial:NO"
+	saftVersion   = "1.30"
+	saftDate      = "2006-01-02"
+)
+
+// SAFT is the Norwegian SAF-T Financial file, version 1.30, holding the general ledger
+// of the bank transactions. Every statement must be in the currency of the file.
+//
+// The general ledger accounts are listed in the master files, with the opening balances
+// of the bank accounts taken from the statements, and those of the counter accounts at zero.
+// Each transaction becomes a transaction of the BANK journal with two lines.
+type SAFT struct {
+	Company  Company
+	Ledger   LedgerMap
+	Currency neo.Currency // Defaults to NOK.
+	Software Software     // Defaults to DefaultSoftware.
+	// When the file is created. Defaults to the current time. Written as a UTC date.
+	CreatedAt time.Time
+}
+
+type saftAuditFile struct {
+	XMLName xml.Name      `xml:"AuditFile"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Header  saftHeader    `xml:"Header"`
+	Master  []saftAccount `xml:"MasterFiles>GeneralLedgerAccounts>Account"`
+	Entries saftEntries   `xml:"GeneralLedgerEntries"`
+}
+
+type saftHeader struct {
+	AuditFileVersion     string        `xml:"AuditFileVersion"`
+	AuditFileCountry     string        `xml:"AuditFileCountry"`
+	AuditFileDateCreated string        `xml:"AuditFileDateCreated"`
+	SoftwareCompanyName  string        `xml:"SoftwareCompanyName"`
+	SoftwareID           string        `xml:"SoftwareID"`
+	SoftwareVersion      string        `xml:"SoftwareVersion"`
+	Company              saftCompany   `xml:"Company"`
+	DefaultCurrencyCode  string        `xml:"DefaultCurrencyCode"`
+	SelectionCriteria    saftSelection `xml:"SelectionCriteria"`
+	TaxAccountingBasis   string        `xml:"TaxAccountingBasis"`
+}
+
+type saftCompany struct {
+	RegistrationNumber string      `xml:"RegistrationNumber"`
+	Name               string      `xml:"Name"`
+	Address            saftAddress `xml:"Address"`
+	Contact            saftContact `xml:"Contact>ContactPerson"`
+	BankAccount        []saftIBAN  `xml:"BankAccount"`
+}
+
+type saftAddress struct {
+	StreetName string `xml:"StreetName,omitempty"`
+	City       string `xml:"City"`
+	PostalCode string `xml:"PostalCode"`
+	Country    string `xml:"Country,omitempty"`
+}
+
+type saftContact struct {
+	FirstName string `xml:"FirstName"`
+	LastName  string `xml:"LastName"`
+}
+
+type saftIBAN struct {
+	IBANNumber        string `xml:"IBANNumber,omitempty"`
+	BankAccountNumber string `xml:"BankAccountNumber,omitempty"`
+}
+
+type saftSelection struct {
+	PeriodStart     int `xml:"PeriodStart"`
+	PeriodStartYear int `xml:"PeriodStartYear"`
+	PeriodEnd       int `xml:"PeriodEnd"`
+	PeriodEndYear   int `xml:"PeriodEndYear"`
+}
+
+// The debit & credit balances are alternatives, so they're pointers to be omitted.
+type saftAccount struct {
+	AccountID            string  `xml:"AccountID"`
+	AccountDescription   string  `xml:"AccountDescription"`
+	StandardAccountID    string  `xml:"StandardAccountID,omitempty"`
+	AccountType          string  `xml:"AccountType"`
+	OpeningDebitBalance  *string `xml:"OpeningDebitBalance,omitempty"`
+	OpeningCreditBalance *string `xml:"OpeningCreditBalance,omitempty"`
+	ClosingDebitBalance  *string `xml:"ClosingDebitBalance,omitempty"`
+	ClosingCreditBalance *string `xml:"ClosingCreditBalance,omitempty"`
+}
+
+type saftEntries struct {
+	NumberOfEntries int         `xml:"NumberOfEntries"`
+	TotalDebit      string      `xml:"TotalDebit"`
+	TotalCredit     string      `xml:"TotalCredit"`
+	Journal         saftJournal `xml:"Journal"`
+}
+
+type saftJournal struct {
+	JournalID   string            `xml:"JournalID"`
+	Description string            `xml:"Description"`
+	Type        string            `xml:"Type"`
+	Transaction []saftTransaction `xml:"Transaction"`
+}
+
+type saftTransaction struct {
+	TransactionID   string     `xml:"TransactionID"`
+	Period          int        `xml:"Period"`
+	PeriodYear      int        `xml:"PeriodYear"`
+	TransactionDate string     `xml:"TransactionDate"`
+	Description     string     `xml:"Description"`
+	SystemEntryDate string     `xml:"SystemEntryDate"`
+	GLPostingDate   string     `xml:"GLPostingDate"`
+	Line            []saftLine `xml:"Line"`
+}
+
+type saftLine struct {
+	RecordID         string      `xml:"RecordID"`
+	AccountID        string      `xml:"AccountID"`
+	ValueDate        string      `xml:"ValueDate,omitempty"`
+	SourceDocumentID string      `xml:"SourceDocumentID,omitempty"`
+	Description      string      `xml:"Description"`
+	DebitAmount      *saftAmount `xml:"DebitAmount,omitempty"`
+	CreditAmount     *saftAmount `xml:"CreditAmount,omitempty"`
+}
+
+type saftAmount struct {
+	Amount string `xml:"Amount"`
+}
+
+// Export writes the general ledger of the statements.
+func (f *SAFT) Export(w io.Writer, statements ...*Statement) error {
+	if f.Company.RegistrationNumber == "" || f.Company.Name == "" {
+		return fmt.Errorf("export: %w: SAF-T requires the registration number & the name", ErrInvalidCompany)
+	}
+	currency := f.Currency
+	if currency == "" {
+		currency = neo.CurrencyNOK
+	}
+	m := f.Ledger
+	if m.DefaultBank.ID == "" {
+		m.DefaultBank = LedgerAccount{ID: "1920", Description: "Bankinnskudd"}
+	}
+	l, err := m.book(statements, currency)
+	if err != nil {
+		return err
+	}
+	sw := f.Software
+	if sw == (Software{}) {
+		sw = DefaultSoftware
+	}
+	created := f.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	created = created.UTC()
+	doc := &saftAuditFile{
+		Xmlns: saftNamespace,
+		Header: saftHeader{
+			AuditFileVersion:     saftVersion,
+			AuditFileCountry:     "NO",
+			AuditFileDateCreated: created.Format(saftDate),
+			SoftwareCompanyName:  sw.Company,
+			SoftwareID:           sw.Name,
+			SoftwareVersion:      sw.Version,
+			Company:              f.company(statements),
+			DefaultCurrencyCode:  string(currency),
+			SelectionCriteria: saftSelection{
+				PeriodStart:     int(l.from.Month()),
+				PeriodStartYear: l.from.Year(),
+				PeriodEnd:       int(l.to.Month()),
+				PeriodEndYear:   l.to.Year(),
+			},
+			TaxAccountingBasis: "A",
+		},
+		Entries: saftEntries{
+			NumberOfEntries: len(l.vouchers),
+			Journal:         saftJournal{JournalID: "BANK", Description: "Bank", Type: "B"},
+		},
+	}
+	for _, a := range l.accounts {
+		doc.Master = append(doc.Master, saftAccountOf(a, l.opening[a.ID], l.closing[a.ID], currency))
+	}
+	var debit, credit neo.Amount
+	for _, v := range l.vouchers {
+		t := saftTransaction{
+			TransactionID:   strconv.Itoa(v.no),
+			Period:          int(v.date.Month()),
+			PeriodYear:      v.date.Year(),
+			TransactionDate: v.date.String(),
+			Description:     v.text,
+			SystemEntryDate: created.Format(saftDate),
+			GLPostingDate:   v.date.String(),
+		}
+		for i, p := range v.postings {
+			line := saftLine{
+				RecordID:         fmt.Sprintf("%d-%d", v.no, i+1),
+				AccountID:        p.account.ID,
+				SourceDocumentID: v.tx.ID,
+				Description:      v.text,
+			}
+			if v.tx.ValueDate != nil && !v.tx.ValueDate.IsZero() {
+				line.ValueDate = v.tx.ValueDate.String()
+			}
+			amount := &saftAmount{Amount: p.amount.Abs().Format(currency)}
+			if p.amount.Sign() < 0 {
+				line.CreditAmount, credit = amount, credit.Add(p.amount.Abs())
+			} else {
+				line.DebitAmount, debit = amount, debit.Add(p.amount)
+			}
+			t.Line = append(t.Line, line)
+		}
+		doc.Entries.Journal.Transaction = append(doc.Entries.Journal.Transaction, t)
+	}
+	doc.Entries.TotalDebit, doc.Entries.TotalCredit = debit.Format(currency), credit.Format(currency)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("export: failed to write SAF-T: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("export: failed to write SAF-T: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("export: failed to write SAF-T: %w", err)
+	}
+	return nil
+}
+
+// company returns the company of the header, with the bank accounts of the statements.
+// The mandatory elements the company lacks are written as NotUsed, as the Norwegian Tax Administration prescribes.
+func (f *SAFT) company(statements []*Statement) saftCompany {
+	c := &f.Company
+	orNotUsed := func(s string) string {
+		if s == "" {
+			return "NotUsed"
+		}
+		return s
+	}
+	sc := saftCompany{
+		RegistrationNumber: c.RegistrationNumber,
+		Name:               c.Name,
+		Address: saftAddress{
+			StreetName: c.Street,
+			City:       orNotUsed(c.City),
+			PostalCode: orNotUsed(c.PostalCode),
+			Country:    c.Country,
+		},
+		Contact: saftContact{FirstName: orNotUsed(c.ContactFirstName), LastName: orNotUsed(c.ContactLastName)},
+	}
+	for _, s := range statements {
+		if s == nil || s.Account == nil {
+			continue
+		}
+		if n := accountNumber(s.Account); neo.ValidateIBAN(n) == nil {
+			sc.BankAccount = append(sc.BankAccount, saftIBAN{IBANNumber: n})
+		} else if n != "" {
+			sc.BankAccount = append(sc.BankAccount, saftIBAN{BankAccountNumber: n})
+		}
+	}
+	return sc
+}
+
+func saftAccountOf(a LedgerAccount, opening, closing neo.Amount, currency neo.Currency) saftAccount {
+	sa := saftAccount{
+		AccountID:          a.ID,
+		AccountDescription: a.Description,
+		StandardAccountID:  a.standardID(),
+		AccountType:        "GL",
+	}
+	balance := func(a neo.Amount) (debit, credit *string) {
+		s := a.Abs().Format(currency)
+		if a.Sign() < 0 {
+			return nil, &s
+		}
+		return &s, nil
+	}
+	sa.OpeningDebitBalance, sa.OpeningCreditBalance = balance(opening)
+	sa.ClosingDebitBalance, sa.ClosingCreditBalance = balance(closing)
+	return sa
+}
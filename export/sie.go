@@ -0,0 +1,163 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+// SIE is the Swedish SIE 4 file of type 4E, holding the vouchers of the bank transactions.
+// Every statement must be in the currency of the file.
+//
+// As the format prescribes, the file is encoded in IBM PC 8-bit extended ASCII, i.e. code page 437,
+// with ø & Ø written as ö & Ö, and the other characters missing from the code page as "?".
+// The balance accounts, those of class 1 & 2, get opening & closing balances,
+// while the result accounts get their result.
+type SIE struct {
+	Company  Company
+	Ledger   LedgerMap
+	Currency neo.Currency // Defaults to SEK.
+	Software Software     // Defaults to DefaultSoftware.
+	Series   string       // The voucher series. Defaults to B.
+	// The financial year. Defaults to the calendar year of the start of the statements.
+	FiscalYearFrom, FiscalYearTo neo.Date
+	// When the file is created. Defaults to the current time.
+	CreatedAt time.Time
+}
+
+// Export writes the vouchers of the statements.
+func (f *SIE) Export(w io.Writer, statements ...*Statement) error { //nolint:cyclop
+	if f.Company.Name == "" {
+		return fmt.Errorf("export: %w: SIE requires the name", ErrInvalidCompany)
+	}
+	currency := f.Currency
+	if currency == "" {
+		currency = neo.CurrencySEK
+	}
+	m := f.Ledger
+	if m.DefaultBank.ID == "" {
+		m.DefaultBank = LedgerAccount{ID: "1930", Description: "Företagskonto"}
+	}
+	l, err := m.book(statements, currency)
+	if err != nil {
+		return err
+	}
+	sw := f.Software
+	if sw == (Software{}) {
+		sw = DefaultSoftware
+	}
+	created := f.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	series := f.Series
+	if series == "" {
+		series = "B"
+	}
+	from, to := f.FiscalYearFrom, f.FiscalYearTo
+	if from.IsZero() || to.IsZero() {
+		from, to = neo.NewDate(l.from.Year(), time.January, 1), neo.NewDate(l.from.Year(), time.December, 31)
+	}
+
+	e := &errWriter{w: w}
+	line := func(format string, args ...interface{}) {
+		e.printf("%s\r\n", cp437(fmt.Sprintf(format, args...)))
+	}
+	line("#FLAGGA 0")
+	line("#PROGRAM %s %s", sieQuote(sw.Name), sieQuote(sw.Version))
+	line("#FORMAT PC8")
+	line("#GEN %s", created.Format("20060102"))
+	line("#SIETYP 4")
+	if f.Company.RegistrationNumber != "" {
+		line("#ORGNR %s", f.Company.RegistrationNumber)
+	}
+	line("#FNAMN %s", sieQuote(f.Company.Name))
+	line("#RAR 0 %s %s", sieDate(from), sieDate(to))
+	line("#VALUTA %s", currency)
+	for _, a := range l.accounts {
+		line("#KONTO %s %s", a.ID, sieQuote(a.Description))
+	}
+	for _, a := range l.accounts {
+		if sieBalanceAccount(a) {
+			line("#IB 0 %s %s", a.ID, l.opening[a.ID].Format(currency))
+		}
+	}
+	for _, a := range l.accounts {
+		if sieBalanceAccount(a) {
+			line("#UB 0 %s %s", a.ID, l.closing[a.ID].Format(currency))
+		}
+	}
+	for _, a := range l.accounts {
+		if !sieBalanceAccount(a) {
+			line("#RES 0 %s %s", a.ID, l.closing[a.ID].Format(currency))
+		}
+	}
+	for _, v := range l.vouchers {
+		line("#VER %s %d %s %s", sieQuote(series), v.no, sieDate(v.date), sieQuote(v.text))
+		line("{")
+		name, _ := counterparty(v.tx)
+		for _, p := range v.postings {
+			line("   #TRANS %s {} %s %s %s", p.account.ID, p.amount.Format(currency), sieDate(v.date), sieQuote(name))
+		}
+		line("}")
+	}
+	if e.err != nil {
+		return fmt.Errorf("export: failed to write SIE: %w", e.err)
+	}
+	return nil
+}
+
+func sieBalanceAccount(a LedgerAccount) bool {
+	return strings.HasPrefix(a.ID, "1") || strings.HasPrefix(a.ID, "2")
+}
+
+func sieDate(d neo.Date) string {
+	return d.In(time.UTC).Format("20060102")
+}
+
+// sieQuote quotes the text as a SIE field, escaping the quotes & dropping the control characters.
+func sieQuote(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// cp437High are the characters of code page 437 from 0x80 to 0xff.
+const cp437High = "ÇüéâäàåçêëèïîìÄÅÉæÆôöòûùÿÖÜ¢£¥₧ƒáíóúñÑªº¿⌐¬½¼¡«»" +
+	"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐└┴┬├─┼╞╟╚╔╩╦╠═╬╧╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀" +
+	"αßΓπΣσµτΦΘΩδ∞φε∩≡±≥≤⌠⌡÷≈°∙·√ⁿ²■\u00a0"
+
+var cp437Table = func() map[rune]byte {
+	t := make(map[rune]byte, 128)
+	i := 0x80
+	for _, r := range cp437High {
+		t[r] = byte(i)
+		i++
+	}
+	// The Nordic letters missing from the code page are written as their Swedish counterparts.
+	t['Ø'], t['ø'] = t['Ö'], t['ö']
+	return t
+}()
+
+// cp437 encodes the text in code page 437.
+func cp437(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch c, ok := cp437Table[r]; {
+		case r < 0x80:
+			b = append(b, byte(r))
+		case ok:
+			b = append(b, c)
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
@@ -0,0 +1,425 @@
+package neo
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidIBAN               = errors.New("invalid IBAN")
+	ErrInvalidBBAN               = errors.New("invalid BBAN")
+	ErrInvalidSortCode           = errors.New("invalid sort code & account number")
+	ErrIBANConversionUnsupported = errors.New("IBAN conversion not supported")
+)
+
+// identifierError is returned by the account identifier validation.
+// It matches both the specific error, e.g. ErrInvalidIBAN, and ErrInvalidAccountInfo.
+type identifierError struct {
+	err    error
+	reason string
+}
+
+func (e *identifierError) Error() string {
+	return fmt.Sprintf("neo: %s: %s", e.err, e.reason)
+}
+
+func (e *identifierError) Is(target error) bool {
+	return target == e.err || target == ErrInvalidAccountInfo //nolint:errorlint
+}
+
+func invalid(err error, format string, args ...interface{}) error {
+	return &identifierError{err: err, reason: fmt.Sprintf(format, args...)}
+}
+
+// rekind reports a validation error as the given kind, e.g. an invalid BBAN within an IBAN as ErrInvalidIBAN.
+func rekind(kind, err error) error {
+	var e *identifierError
+	if errors.As(err, &e) {
+		return &identifierError{err: kind, reason: e.reason}
+	}
+	return err
+}
+
+// NormalizeIBAN strips the whitespace from the IBAN and upper-cases it.
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// NormalizeBBAN strips the whitespace & the usual separators (".", "-", ",") from the BBAN and upper-cases it.
+func NormalizeBBAN(bban string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '.', '-', ',':
+			return -1
+		}
+		return r
+	}, bban))
+}
+
+// NormalizeSortCodeAccountNumber strips the whitespace & dashes from a UK sort code & account number.
+func NormalizeSortCodeAccountNumber(s string) string {
+	return NormalizeBBAN(s)
+}
+
+// ValidateIBAN checks the country specific length & format, and the mod-97 check digits of the IBAN.
+// The national check digits of the contained BBAN are not validated, see ValidateBBAN.
+func ValidateIBAN(iban string) error {
+	iban = NormalizeIBAN(iban)
+	if len(iban) < 5 || !isLetters(iban[:2]) || !isDigits(iban[2:4]) || !isAlphanumeric(iban[4:]) {
+		return invalid(ErrInvalidIBAN, "malformed %q", iban)
+	}
+	country := iban[:2]
+	n, ok := ibanLengths[country]
+	if !ok {
+		return invalid(ErrInvalidIBAN, "unknown country %s", country)
+	}
+	if len(iban) != n {
+		return invalid(ErrInvalidIBAN, "%s IBANs are %d characters long, not %d", country, n, len(iban))
+	}
+	if mod97(iban[4:]+iban[:4]) != 1 {
+		return invalid(ErrInvalidIBAN, "invalid check digits in %s", iban)
+	}
+	return rekind(ErrInvalidIBAN, validateIBANBBAN(country, iban[4:]))
+}
+
+// ValidateBBAN validates the national account number of the given ISO 3166 country:
+//
+//   - NO: 11 digits with a mod-11 check digit.
+//   - DK: a 4 digit registration number & up to 10 digit account number. Danish check digits
+//     are bank specific, so only the format is validated.
+//   - SE: a clearing number & an account number, validated using the check digit scheme
+//     of the bank the clearing number belongs to. The 20 digit IBAN form is accepted as well.
+//   - FI: the short (e.g. 123456-785) or the 14 digit machine format with a Luhn check digit.
+//   - GB: a sort code & account number, optionally prefixed with the 4 letter bank code.
+//
+// The BBANs of other countries are validated against the length of their IBANs.
+func ValidateBBAN(country, bban string) error {
+	country = strings.ToUpper(country)
+	bban = NormalizeBBAN(bban)
+	if bban == "" || !isAlphanumeric(bban) {
+		return invalid(ErrInvalidBBAN, "malformed %q", bban)
+	}
+	switch country {
+	case "NO":
+		return validateNOBBAN(bban)
+	case "DK":
+		_, err := expandDKBBAN(bban)
+		return err
+	case "SE":
+		return validateSEBBAN(bban)
+	case "FI":
+		_, err := expandFIBBAN(bban)
+		return err
+	case "GB":
+		if len(bban) == 18 && isLetters(bban[:4]) {
+			bban = bban[4:]
+		}
+		return rekind(ErrInvalidBBAN, ValidateSortCodeAccountNumber(bban))
+	}
+	n, ok := ibanLengths[country]
+	if !ok {
+		return invalid(ErrInvalidBBAN, "unknown country %s", country)
+	}
+	if len(bban) != n-4 {
+		return invalid(ErrInvalidBBAN, "%s BBANs are %d characters long, not %d", country, n-4, len(bban))
+	}
+	return nil
+}
+
+// ValidateSortCodeAccountNumber checks the format of a UK sort code (6 digits)
+// followed by an account number (8 digits), e.g. "60-16-13 31926819".
+func ValidateSortCodeAccountNumber(s string) error {
+	s = NormalizeSortCodeAccountNumber(s)
+	if len(s) != 14 || !isDigits(s) {
+		return invalid(ErrInvalidSortCode, "expected a 6 digit sort code & an 8 digit account number, got %q", s)
+	}
+	return nil
+}
+
+// IBANToBBAN returns the national account number contained in the IBAN.
+// This is only supported for the countries where the BBAN is used as-is in domestic payments: NO, DK & FI.
+func IBANToBBAN(iban string) (string, error) {
+	iban = NormalizeIBAN(iban)
+	if err := ValidateIBAN(iban); err != nil {
+		return "", err
+	}
+	if !ibanConvertible(iban[:2]) {
+		return "", fmt.Errorf("neo: %w for %s", ErrIBANConversionUnsupported, iban[:2])
+	}
+	return iban[4:], nil
+}
+
+// BBANToIBAN returns the IBAN of the national account number of the given ISO 3166 country.
+// This is only supported for NO, DK & FI. Other countries (e.g. SE & GB) require a bank code lookup.
+func BBANToIBAN(country, bban string) (string, error) {
+	country = strings.ToUpper(country)
+	if !ibanConvertible(country) {
+		return "", fmt.Errorf("neo: %w for %s", ErrIBANConversionUnsupported, country)
+	}
+	if err := ValidateBBAN(country, bban); err != nil {
+		return "", err
+	}
+	bban = NormalizeBBAN(bban)
+	switch country {
+	case "DK":
+		bban, _ = expandDKBBAN(bban)
+	case "FI":
+		bban, _ = expandFIBBAN(bban)
+	}
+	check := 98 - mod97(bban+country+"00")
+	return fmt.Sprintf("%s%02d%s", country, check, bban), nil
+}
+
+func ibanConvertible(country string) bool {
+	return country == "NO" || country == "DK" || country == "FI"
+}
+
+// validateIBANBBAN validates the format of the BBAN part of an IBAN, if the country has a known national format.
+func validateIBANBBAN(country, bban string) error {
+	switch country {
+	case "NO", "DK", "SE", "FI":
+		if !isDigits(bban) {
+			return invalid(ErrInvalidBBAN, "%s BBANs consist of digits only", country)
+		}
+	case "GB":
+		if !isLetters(bban[:4]) || !isDigits(bban[4:]) {
+			return invalid(ErrInvalidBBAN, "GB BBANs consist of a bank code, a sort code & an account number")
+		}
+	}
+	return nil
+}
+
+// validateNOBBAN validates a Norwegian account number using the mod-11 check digit.
+func validateNOBBAN(bban string) error {
+	if len(bban) != 11 || !isDigits(bban) {
+		return invalid(ErrInvalidBBAN, "NO BBANs consist of 11 digits, got %q", bban)
+	}
+	if !mod11(bban, []int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2, 1}) {
+		return invalid(ErrInvalidBBAN, "invalid check digit in %s", bban)
+	}
+	return nil
+}
+
+// expandDKBBAN validates a Danish registration & account number, returning the 14 digit BBAN.
+func expandDKBBAN(bban string) (string, error) {
+	if len(bban) < 5 || len(bban) > 14 || !isDigits(bban) {
+		return "", invalid(ErrInvalidBBAN, "DK BBANs consist of a 4 digit registration number & up to 10 digits, got %q", bban)
+	}
+	return bban[:4] + strings.Repeat("0", 14-len(bban)) + bban[4:], nil
+}
+
+// expandFIBBAN validates a Finnish account number, returning the 14 digit machine format.
+func expandFIBBAN(bban string) (string, error) {
+	if len(bban) < 8 || len(bban) > 14 || !isDigits(bban) {
+		return "", invalid(ErrInvalidBBAN, "FI BBANs consist of 8 to 14 digits, got %q", bban)
+	}
+	if len(bban) < 14 {
+		// Savings banks & POP banks (4 & 5) pad after the 7th digit, others after the 6th.
+		at := 6
+		if bban[0] == '4' || bban[0] == '5' {
+			at = 7
+		}
+		bban = bban[:at] + strings.Repeat("0", 14-len(bban)) + bban[at:]
+	}
+	if !luhn(bban) {
+		return "", invalid(ErrInvalidBBAN, "invalid check digit in %s", bban)
+	}
+	return bban, nil
+}
+
+// Check digit schemes used by the Swedish banks, as per the Bankgirot specification.
+const (
+	seType1Comment1 = iota + 1 // Mod-11 over the last 3 clearing digits & the 7 digit account.
+	seType1Comment2            // Mod-11 over the 4 clearing digits & the 7 digit account.
+	seType2Comment1            // Mod-10 over the 10 digit account.
+	seType2Comment2            // Mod-11 over the 9 digit account.
+	seType2Comment3            // Mod-10 over the up to 10 digit account.
+)
+
+type seClearingRange struct {
+	from, to int
+	scheme   int
+}
+
+var seClearingRanges = []seClearingRange{
+	{1100, 1199, seType1Comment1}, // Nordea
+	{1200, 1399, seType1Comment1}, // Danske Bank
+	{1400, 2099, seType1Comment1}, // Nordea
+	{2300, 2399, seType1Comment1}, // Ålandsbanken
+	{2400, 2499, seType1Comment1}, // Danske Bank
+	{3000, 3299, seType1Comment1}, // Nordea
+	{3300, 3300, seType2Comment1}, // Nordea personkonto
+	{3301, 3399, seType1Comment1}, // Nordea
+	{3400, 3409, seType1Comment1}, // Länsförsäkringar Bank
+	{3410, 3781, seType1Comment1}, // Nordea
+	{3782, 3782, seType2Comment1}, // Nordea personkonto
+	{3783, 3999, seType1Comment1}, // Nordea
+	{4000, 4999, seType1Comment2}, // Nordea
+	{5000, 5999, seType1Comment1}, // SEB
+	{6000, 6999, seType2Comment2}, // Handelsbanken
+	{7000, 7999, seType1Comment1}, // Swedbank
+	{8000, 8999, seType2Comment3}, // Swedbank
+	{9020, 9029, seType1Comment2}, // Länsförsäkringar Bank
+	{9040, 9049, seType1Comment1}, // Citibank
+	{9060, 9069, seType1Comment1}, // Länsförsäkringar Bank
+	{9100, 9109, seType1Comment1}, // Nordnet Bank
+	{9120, 9124, seType1Comment1}, // SEB
+	{9130, 9149, seType1Comment1}, // SEB
+	{9150, 9169, seType1Comment1}, // Skandiabanken
+	{9170, 9179, seType1Comment1}, // Ikano Bank
+	{9180, 9189, seType2Comment1}, // Danske Bank
+	{9190, 9199, seType1Comment1}, // DNB Bank
+	{9230, 9239, seType1Comment1}, // Marginalen Bank
+	{9250, 9259, seType1Comment1}, // SBAB
+	{9260, 9269, seType1Comment1}, // DNB Bank
+	{9270, 9279, seType1Comment1}, // ICA Banken
+	{9280, 9289, seType1Comment1}, // Resurs Bank
+	{9300, 9349, seType2Comment1}, // Sparbanken Öresund
+	{9390, 9399, seType1Comment1}, // Landshypotek
+	{9400, 9449, seType1Comment1}, // Forex Bank
+	{9460, 9469, seType1Comment1}, // Santander Consumer Bank
+	{9470, 9479, seType1Comment1}, // BNP Paribas
+	{9500, 9549, seType2Comment3}, // Nordea Plusgirot
+	{9550, 9569, seType1Comment1}, // Avanza Bank
+	{9570, 9579, seType2Comment1}, // Sparbanken Syd
+	{9590, 9599, seType1Comment1}, // Erik Penser
+	{9630, 9639, seType1Comment1}, // Lån & Spar Bank
+	{9640, 9649, seType1Comment1}, // Nordax Bank
+	{9660, 9669, seType1Comment2}, // Svea Bank
+	{9680, 9689, seType1Comment1}, // BlueStep Finans
+	{9700, 9709, seType1Comment1}, // Ekobanken
+	{9880, 9889, seType1Comment2}, // Riksgälden
+	{9890, 9899, seType2Comment1}, // Riksgälden
+	{9960, 9969, seType2Comment3}, // Nordea Plusgirot
+}
+
+// validateSEBBAN validates a Swedish clearing & account number. Clearing numbers
+// outside of the known ranges are validated by their format only.
+func validateSEBBAN(bban string) error { //nolint:cyclop
+	if !isDigits(bban) {
+		return invalid(ErrInvalidBBAN, "SE BBANs consist of digits only, got %q", bban)
+	}
+	if len(bban) == 20 {
+		return nil // The IBAN form, validated by the IBAN check digits.
+	}
+	clearingLen := 4
+	if bban[0] == '8' {
+		clearingLen = 5 // Swedbank clearing numbers carry a check digit.
+	}
+	if len(bban) <= clearingLen || len(bban) > clearingLen+10 {
+		return invalid(ErrInvalidBBAN, "SE BBANs consist of a clearing number & up to 10 digits, got %q", bban)
+	}
+	clearing, account := bban[:clearingLen], bban[clearingLen:]
+	n, _ := strconv.Atoi(clearing[:4])
+	var scheme int
+	for _, r := range seClearingRanges {
+		if n >= r.from && n <= r.to {
+			scheme = r.scheme
+			break
+		}
+	}
+	var ok bool
+	switch scheme {
+	case seType1Comment1:
+		ok = len(account) == 7 && mod11(clearing[1:]+account, nil)
+	case seType1Comment2:
+		ok = len(account) == 7 && mod11(clearing+account, nil)
+	case seType2Comment1:
+		ok = len(account) == 10 && luhn(account)
+	case seType2Comment2:
+		ok = len(account) <= 9 && mod11(strings.Repeat("0", 9-len(account))+account, nil)
+	case seType2Comment3:
+		ok = luhn(account)
+	default:
+		ok = true
+	}
+	if !ok {
+		return invalid(ErrInvalidBBAN, "invalid SE account number %s-%s", clearing, account)
+	}
+	return nil
+}
+
+// mod11 reports whether the weighted digit sum is divisible by 11.
+// If no weights are given, the weights 1, 2, ..., 10, 1, 2, ... are applied from the right.
+func mod11(digits string, weights []int) bool {
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		w := i%10 + 1
+		if weights != nil {
+			w = weights[len(weights)-1-i]
+		}
+		sum += d * w
+	}
+	return sum%11 == 0
+}
+
+// luhn reports whether the digits end with a valid Luhn (mod-10) check digit.
+func luhn(digits string) bool {
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// mod97 computes the ISO 7064 mod 97-10 remainder, with letters converted to numbers (A = 10, ..., Z = 35).
+func mod97(s string) int64 {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	n, ok := new(big.Int).SetString(b.String(), 10)
+	if !ok {
+		return -1
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64()
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < 'A' || s[i] > 'Z') && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// ibanLengths contains the IBAN length of each country in the SWIFT IBAN registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HN": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26,
+	"IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20,
+	"LU": 20, "LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20,
+	"MR": 27, "MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24,
+	"PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24,
+	"SC": 31, "SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25,
+	"SV": 28, "TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+	"YE": 30,
+}
@@ -0,0 +1,89 @@
+package neo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/enfunc/neo"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	for _, iban := range []string{
+		"NO9386011117947",
+		"no93 8601 1117 947",
+		"DK5000400440116243",
+		"SE4550000000058398257466",
+		"FI2112345600000785",
+		"GB29 NWBK 6016 1331 9268 19",
+		"DE89370400440532013000",
+	} {
+		if err := neo.ValidateIBAN(iban); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, iban := range []string{
+		"NO9386011117948",    // Invalid check digits.
+		"NO938601111794",     // Invalid length.
+		"XX9386011117947",    // Unknown country.
+		"FI2112345600000786", // Invalid check digits.
+	} {
+		err := neo.ValidateIBAN(iban)
+		if !errors.Is(err, neo.ErrInvalidIBAN) || !errors.Is(err, neo.ErrInvalidAccountInfo) {
+			t.Fatalf("TestValidateIBAN: %s should be invalid, got %v", iban, err)
+		}
+	}
+}
+
+func TestValidateBBAN(t *testing.T) {
+	for _, tc := range []struct{ country, bban string }{
+		{"NO", "8601.11.17947"},
+		{"NO", "90412263056"},
+		{"DK", "0040 0440116243"},
+		{"FI", "123456-785"},
+		{"FI", "423456-781"},
+		{"SE", "5000 0000058398257466"},
+		{"SE", "5491-0000003"},
+		{"SE", "6789 123456789"},
+		{"GB", "60-16-13 31926819"},
+	} {
+		if err := neo.ValidateBBAN(tc.country, tc.bban); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range []struct{ country, bban string }{
+		{"NO", "86011117948"},
+		{"FI", "123456-786"},
+		{"SE", "5491-0000004"},
+		{"GB", "60-16-13 3192681"},
+	} {
+		if err := neo.ValidateBBAN(tc.country, tc.bban); !errors.Is(err, neo.ErrInvalidBBAN) {
+			t.Fatalf("TestValidateBBAN: %s %s should be invalid, got %v", tc.country, tc.bban, err)
+		}
+	}
+}
+
+func TestIBANConversion(t *testing.T) {
+	for country, tc := range map[string]struct{ bban, iban string }{
+		"NO": {"8601 11 17947", "NO9386011117947"},
+		"DK": {"0040 0440116243", "DK5000400440116243"},
+		"FI": {"123456-785", "FI2112345600000785"},
+	} {
+		iban, err := neo.BBANToIBAN(country, tc.bban)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if iban != tc.iban {
+			t.Fatalf("TestIBANConversion: %s != %s", iban, tc.iban)
+		}
+		bban, err := neo.IBANToBBAN(iban)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back, _ := neo.BBANToIBAN(country, bban); back != iban {
+			t.Fatalf("TestIBANConversion: %s != %s", back, iban)
+		}
+	}
+	if _, err := neo.IBANToBBAN("SE4550000000058398257466"); !errors.Is(err, neo.ErrIBANConversionUnsupported) {
+		t.Fatal("TestIBANConversion: SE conversion should be unsupported")
+	}
+}
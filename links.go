@@ -1,5 +1,7 @@
 package neo
 
+import "strings"
+
 type Link struct {
 	Type string `json:"type"`
 	Rel  string `json:"rel"`
@@ -8,5 +10,30 @@ type Link struct {
 }
 
 type Meta struct {
-	ID string `json:"id"`
+	ID     string   `json:"id"`
+	Fields []string `json:"fields,omitempty"` // Credentials requested by an embedded SCA.
+}
+
+// LinkRelNext is the relation of a link to the next page of a paginated response.
+const LinkRelNext = "next"
+
+// nextLink returns the URL of the next page from an RFC 8288 Link header, e.g. `<https://...>; rel="next"`.
+func nextLink(header string) string {
+	for _, l := range strings.Split(header, ",") {
+		href, params, ok := strings.Cut(l, ";")
+		if !ok {
+			continue
+		}
+		href = strings.TrimSpace(href)
+		if !strings.HasPrefix(href, "<") || !strings.HasSuffix(href, ">") {
+			continue
+		}
+		for _, p := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "rel") && strings.EqualFold(strings.Trim(v, `"`), LinkRelNext) {
+				return href[1 : len(href)-1]
+			}
+		}
+	}
+	return ""
 }
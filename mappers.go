@@ -0,0 +1,104 @@
+package neo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrSCAHostNotAllowed = errors.New("SCA host not allowed")
+
+// SCAMiddleware wraps an SCAMapper to extend its behavior.
+type SCAMiddleware func(next SCAMapper) SCAMapper
+
+// SCASink receives every SCA mapped by the API, or the error that occurred while mapping it.
+type SCASink func(r *http.Request, sca *SCA, err error)
+
+// ChainMappers wraps m with the given middleware, e.g.:
+//
+//	api.Mapper = neo.ChainMappers(neo.DefaultSCAMapper, neo.AllowHosts("bank.no"), neo.AuditSCA(sink))
+//
+// Middleware is applied in order, so each one sees the SCA produced by the ones before it.
+func ChainMappers(m SCAMapper, mw ...SCAMiddleware) SCAMapper {
+	for _, w := range mw {
+		m = w(m)
+	}
+	return m
+}
+
+// RewriteURL replaces the redirect URL of each SCA with the one returned by rewrite.
+func RewriteURL(rewrite func(sca *SCA) (string, error)) SCAMiddleware {
+	return func(next SCAMapper) SCAMapper {
+		return func(d Doer, r *http.Request, e *Error) (*SCA, error) {
+			sca, err := next(d, r, e)
+			if err != nil || sca == nil || sca.Type != SCARedirect {
+				return sca, err
+			}
+			u, err := rewrite(sca)
+			if err != nil {
+				return nil, fmt.Errorf("neo: failed to rewrite the SCA URL: %w", err)
+			}
+			sca.URL = u
+			return sca, nil
+		}
+	}
+}
+
+// UniversalLink rewrites each redirect URL into the given universal link,
+// passing the original URL in the query parameter param.
+func UniversalLink(base, param string) SCAMiddleware {
+	return RewriteURL(func(sca *SCA) (string, error) {
+		u, err := url.Parse(base)
+		if err != nil {
+			return "", fmt.Errorf("invalid universal link %q: %w", base, err)
+		}
+		q := u.Query()
+		q.Set(param, sca.URL)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	})
+}
+
+// AuditSCA records every mapped SCA, successful or not, to the given sink.
+func AuditSCA(sink SCASink) SCAMiddleware {
+	return func(next SCAMapper) SCAMapper {
+		return func(d Doer, r *http.Request, e *Error) (*SCA, error) {
+			sca, err := next(d, r, e)
+			sink(r, sca, err)
+			return sca, err
+		}
+	}
+}
+
+// AllowHosts rejects any SCA whose URL host is neither one of the given hosts nor a subdomain of one.
+func AllowHosts(hosts ...string) SCAMiddleware {
+	return func(next SCAMapper) SCAMapper {
+		return func(d Doer, r *http.Request, e *Error) (*SCA, error) {
+			sca, err := next(d, r, e)
+			if err != nil || sca == nil || sca.URL == "" {
+				return sca, err
+			}
+			u, err := url.Parse(sca.URL)
+			if err != nil {
+				return nil, fmt.Errorf("neo: invalid SCA URL: %w", err)
+			}
+			if !hostAllowed(u.Hostname(), hosts) {
+				return nil, fmt.Errorf("neo: %w: %s", ErrSCAHostNotAllowed, u.Hostname())
+			}
+			return sca, nil
+		}
+	}
+}
+
+func hostAllowed(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package neo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/enfunc/neo"
+)
+
+func TestChainMappers(t *testing.T) {
+	r, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "https://sandbox.neonomics.io", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &neo.Error{Links: []*neo.Link{{Href: "https://consent.bank.no/auth?id=1", Meta: neo.Meta{ID: "session"}}}}
+
+	var audited []*neo.SCA
+	m := neo.ChainMappers(
+		neo.DefaultSCAMapper,
+		neo.AllowHosts("bank.no"),
+		neo.UniversalLink("https://app.example.com/consent", "to"),
+		neo.AuditSCA(func(_ *http.Request, sca *neo.SCA, _ error) {
+			audited = append(audited, sca)
+		}),
+	)
+	sca, err := m(http.DefaultClient, r, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://app.example.com/consent?to=https%3A%2F%2Fconsent.bank.no%2Fauth%3Fid%3D1"
+	if sca.URL != want {
+		t.Fatalf("TestChainMappers: %s != %s", sca.URL, want)
+	}
+	if len(audited) != 1 || audited[0] != sca {
+		t.Fatal("TestChainMappers: SCA not audited")
+	}
+
+	m = neo.ChainMappers(neo.DefaultSCAMapper, neo.AllowHosts("other.no"))
+	if _, err := m(http.DefaultClient, r, e); !errors.Is(err, neo.ErrSCAHostNotAllowed) {
+		t.Fatalf("TestChainMappers: expected ErrSCAHostNotAllowed, got %v", err)
+	}
+}
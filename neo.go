@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Doer interface {
@@ -37,11 +39,24 @@ func NewClient(client, secret, baseURL string, doer Doer) *Client {
 	}
 }
 
+// DefaultSCATTL is the assumed lifetime of an SCA if neither the SCAMapper nor API.SCATTL specify one.
+// The Neonomics platform doesn't report when a consent URL expires.
+const DefaultSCATTL = 10 * time.Minute
+
 type API struct {
 	Client   *Client
 	Token    *Token
 	DeviceID string
 	Mapper   SCAMapper
+	SCATTL   time.Duration // How long an SCA stays valid. Defaults to DefaultSCATTL.
+
+	mu sync.RWMutex // Guards Token, which is refreshed by concurrent requests.
+}
+
+func (a *API) token() *Token {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.Token
 }
 
 func (c *Client) API(ctx context.Context, deviceID string) (*API, error) {
@@ -57,6 +72,13 @@ func (c *Client) API(ctx context.Context, deviceID string) (*API, error) {
 	}, nil
 }
 
+func (a *API) scaTTL() time.Duration {
+	if a.SCATTL > 0 {
+		return a.SCATTL
+	}
+	return DefaultSCATTL
+}
+
 const (
 	ContentTypeFormURLEncoded = "application/x-www-form-urlencoded"
 	ContentTypeJSON           = "application/json"
@@ -66,11 +88,14 @@ const (
 	HeaderPSUID       = "x-psu-id"
 	HeaderPSUIP       = "x-psu-ip-address"
 	HeaderDeviceID    = "x-device-id"
+
+	HeaderContinuationKey = "x-continuation-key"
+	HeaderLink            = "link"
 )
 
 // Optional provides means to adjust the request sent to the server.
 // In most cases, you should use one of the provided helpers:
-// SessionID, RedirectURL, PsuID, PsuIP, DeviceID.
+// SessionID, RedirectURL, PsuID, PsuIP, DeviceID, FromDate, ToDate.
 type Optional func(*http.Request)
 
 // SessionID appends a session ID header to the request.
@@ -111,12 +136,22 @@ func DeviceID(id string) Optional {
 }
 
 func (a *API) request(ctx context.Context, method, url string, body io.Reader, opts ...Optional) *http.Request {
+	r, err := a.newRequest(ctx, method, url, body, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// newRequest is like request, but returns the error instead of panicking,
+// for URLs which aren't built by the library itself, e.g. those of the platform responses.
+func (a *API) newRequest(ctx context.Context, method, url string, body io.Reader, opts ...Optional) (*http.Request, error) {
 	if !strings.HasPrefix(url, "http") {
 		url = a.Client.baseURL + url
 	}
 	r, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("neo: failed to create a new http.Request: %w", err)
 	}
 	r.Header.Set(HeaderDeviceID, a.DeviceID)
 	for _, opt := range opts {
@@ -124,12 +159,23 @@ func (a *API) request(ctx context.Context, method, url string, body io.Reader, o
 	}
 	// Append non-modifiable headers. This overrides any previously set headers with the same key.
 	r.Header.Set("accept", ContentTypeJSON)
-	r.Header.Set("authorization", "Bearer "+a.Token.AccessToken)
+	r.Header.Set("authorization", "Bearer "+a.token().AccessToken)
 	r.Header.Set("content-type", ContentTypeJSON)
-	return r
+	return r, nil
+}
+
+func (a *API) do(req *http.Request, status int, v interface{}) (*SCAHandler, error) {
+	return a.send(req, status, v, a.Mapper)
+}
+
+// headerReceiver is implemented by response types which also read the response headers,
+// e.g. to retrieve the pagination links.
+type headerReceiver interface {
+	receiveHeader(h http.Header)
 }
 
-func (a *API) do(req *http.Request, status int, v interface{}) (*SCAHandler, error) { //nolint:cyclop
+// send executes the request, mapping consent errors using the given mapper, if any.
+func (a *API) send(req *http.Request, status int, v interface{}, mapper SCAMapper) (*SCAHandler, error) { //nolint:cyclop
 	resp, err := a.Client.doer.Do(req) //nolint:bodyclose
 	if err != nil {
 		return nil, fmt.Errorf("%s err: %w", req.URL.String(), err)
@@ -142,33 +188,53 @@ func (a *API) do(req *http.Request, status int, v interface{}) (*SCAHandler, err
 				return nil, fmt.Errorf("neo: failed to decode JSON: %w", err)
 			}
 		}
+		if h, ok := v.(headerReceiver); ok {
+			h.receiveHeader(resp.Header)
+		}
 		return nil, nil
 	case http.StatusUnauthorized:
 		c := req.Context()
-		t, err := a.Client.RefreshToken(c, a.Token)
+		t, err := a.Client.RefreshToken(c, a.token())
 		if err != nil {
 			return nil, fmt.Errorf("neo: failed to refresh token: %w", err)
 		}
+		a.mu.Lock()
 		a.Token = t
-		return a.do(req.Clone(c), status, v)
+		a.mu.Unlock()
+		req = req.Clone(c)
+		req.Header.Set("authorization", "Bearer "+t.AccessToken)
+		return a.send(req, status, v, mapper)
 	case 510, 520, 530: //nolint:usestdlibvars
 		// See https://docs.neonomics.io/documentation/development/error-handling.
 		e := &Error{}
 		if err := json.NewDecoder(resp.Body).Decode(e); err != nil {
 			return nil, fmt.Errorf("neo: failed to decode neo.Error: %w", err)
 		}
-		if a.Mapper == nil || !(e.IsConsentError() || e.IsPaymentAuthError()) {
+		if mapper == nil || !(e.IsConsentError() || e.IsPaymentAuthError()) {
 			return nil, e
 		}
-		sca, err := a.Mapper(a.Client.doer, req, e)
+		sca, err := mapper(a.Client.doer, req, e)
 		if err != nil {
 			return nil, fmt.Errorf("neo: SCAMapper: %w", err)
 		}
+		if sca.IssuedAt.IsZero() {
+			sca.IssuedAt = time.Now()
+		}
+		if sca.TTL == 0 {
+			sca.TTL = a.scaTTL()
+		}
+		sessionID := req.Header.Get(HeaderSessionID)
+		if sessionID == "" && !e.IsPaymentAuthError() {
+			sessionID = sca.ID
+		}
 		return &SCAHandler{
 			SCA: sca,
 			Retry: func(ctx context.Context, u interface{}) (*SCAHandler, error) {
 				return a.do(req.Clone(ctx), status, u)
 			},
+			Cancel: func(ctx context.Context) error {
+				return a.DeleteSession(ctx, sessionID)
+			},
 		}, nil
 	default:
 		return nil, fmt.Errorf("unexpected HTTP response: %s", resp.Status)
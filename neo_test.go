@@ -3,8 +3,10 @@ package neo_test
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -53,6 +55,33 @@ func sandboxAPI(ctx context.Context) *neo.API {
 	return a
 }
 
+type doerFunc func(r *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// fakeAPI creates an API sending the requests to the given Doer.
+func fakeAPI(d neo.Doer) *neo.API {
+	return &neo.API{
+		Client: neo.NewClient("client", "secret", "https://neo.test", d),
+		Token:  &neo.Token{AccessToken: "token"},
+		Mapper: neo.DefaultSCAMapper,
+	}
+}
+
+func response(status int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
 func waitForConsent(sca *neo.SCA) {
 	fmt.Println(sca.URL)
 	time.Sleep(15 * time.Second)
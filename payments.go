@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -36,6 +37,11 @@ func (p PaymentType) OK() error {
 	return ErrInvalidPaymentType
 }
 
+// IsSEPA reports whether the payment type is a SEPA credit transfer, which must be made in euro.
+func (p PaymentType) IsSEPA() bool {
+	return p == PaymentTypeSEPA || p == PaymentTypeSEPAScheduled
+}
+
 var (
 	PaymentCodeCommercial PaymentCode = "GDDS"
 	PaymentCodeInvoice    PaymentCode = "IVPT"
@@ -59,11 +65,11 @@ type PaymentRequest struct {
 	CreditorName               string                    `json:"creditorName"`
 	RemittanceInfoUnstructured string                    `json:"remittanceInformationUnstructured,omitempty"`
 	RemittanceInfoStructured   *RemittanceInfoStructured `json:"remittanceInformationStructured,omitempty"`
-	InstrumentedAmount         string                    `json:"instrumentedAmount"`
-	Currency                   string                    `json:"currency"`
+	InstrumentedAmount         Amount                    `json:"instrumentedAmount"`
+	Currency                   Currency                  `json:"currency"`
 	EndToEndIdentification     string                    `json:"endToEndIdentification"`
 	PaymentMetadata            *PaymentMetadata          `json:"paymentMetadata,omitempty"`
-	RequestedExecutionDate     *time.Time                `json:"requestedExecutionDate,omitempty"`
+	RequestedExecutionDate     *Date                     `json:"requestedExecutionDate,omitempty"`
 }
 
 func (r *PaymentRequest) OK() error {
@@ -73,14 +79,18 @@ func (r *PaymentRequest) OK() error {
 	for _, s := range []string{
 		r.DebtorName,
 		r.CreditorName,
-		r.InstrumentedAmount,
-		r.Currency,
 		r.EndToEndIdentification,
 	} {
 		if s == "" {
 			return ErrInvalidPaymentRequest
 		}
 	}
+	if err := r.Currency.OK(); err != nil {
+		return err
+	}
+	if r.InstrumentedAmount.Sign() <= 0 || r.InstrumentedAmount.Decimals() > r.Currency.MinorUnits() {
+		return ErrInvalidAmount
+	}
 	if r.RemittanceInfoUnstructured == "" {
 		if err := r.RemittanceInfoStructured.OK(); err != nil {
 			return err
@@ -99,19 +109,90 @@ func (r *PaymentRequest) OK() error {
 	return nil
 }
 
+// MarshalJSON sends the amount with the number of decimals used by the currency, e.g. "12.50" for EUR,
+// and the normalized currency code.
+func (r *PaymentRequest) MarshalJSON() ([]byte, error) {
+	type paymentRequest PaymentRequest
+	b, err := json.Marshal(&struct {
+		*paymentRequest
+		InstrumentedAmount string   `json:"instrumentedAmount"`
+		Currency           Currency `json:"currency"`
+	}{
+		paymentRequest:     (*paymentRequest)(r),
+		InstrumentedAmount: r.InstrumentedAmount.Format(r.Currency),
+		Currency:           r.Currency.Normalized(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal payment request: %w", err)
+	}
+	return b, nil
+}
+
+// OKFor validates the request for the given payment type, e.g. SEPA payments must be made in euro.
+func (r *PaymentRequest) OKFor(paymentType PaymentType) error {
+	if err := r.OK(); err != nil {
+		return err
+	}
+	if paymentType.IsSEPA() && r.Currency.Normalized() != CurrencyEUR {
+		return fmt.Errorf("neo: %w: SEPA payments must be made in EUR, not %s", ErrInvalidCurrency, r.Currency)
+	}
+	return nil
+}
+
 type AccountInfo struct {
 	BBAN                  string `json:"bban,omitempty"`
 	IBAN                  string `json:"iban,omitempty"`
 	SortCodeAccountNumber string `json:"sortCodeAccountNumber,omitempty"`
+	Country               string `json:"-"` // The ISO 3166 country of the BBAN. If empty, the BBAN is not validated.
 }
 
+// OK validates the provided account identifiers. See ValidateIBAN, ValidateBBAN & ValidateSortCodeAccountNumber.
 func (info *AccountInfo) OK() error {
 	if info == nil || (info.BBAN == "" && info.IBAN == "" && info.SortCodeAccountNumber == "") {
 		return ErrInvalidAccountInfo
 	}
+	if info.IBAN != "" {
+		if err := ValidateIBAN(info.IBAN); err != nil {
+			return err
+		}
+	}
+	if info.BBAN != "" && info.Country != "" {
+		if err := ValidateBBAN(info.Country, info.BBAN); err != nil {
+			return err
+		}
+	}
+	if info.SortCodeAccountNumber != "" {
+		if err := ValidateSortCodeAccountNumber(info.SortCodeAccountNumber); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Normalized returns a copy of the account info with the identifiers normalized,
+// e.g. "no93 8601 1117 947" becomes "NO9386011117947".
+func (info *AccountInfo) Normalized() *AccountInfo {
+	if info == nil {
+		return nil
+	}
+	return &AccountInfo{
+		BBAN:                  NormalizeBBAN(info.BBAN),
+		IBAN:                  NormalizeIBAN(info.IBAN),
+		SortCodeAccountNumber: NormalizeSortCodeAccountNumber(info.SortCodeAccountNumber),
+		Country:               strings.ToUpper(info.Country),
+	}
+}
+
+// MarshalJSON sends the normalized identifiers to the platform.
+func (info *AccountInfo) MarshalJSON() ([]byte, error) {
+	type accountInfo AccountInfo
+	b, err := json.Marshal((*accountInfo)(info.Normalized()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal account info: %w", err)
+	}
+	return b, nil
+}
+
 type RemittanceInfoStructured struct {
 	Reference string `json:"reference,omitempty"`
 	Issuer    string `json:"referenceIssuer,omitempty"`
@@ -166,7 +247,7 @@ func (a *API) payment(
 	if err := paymentType.OK(); err != nil {
 		return nil, nil, err
 	}
-	if err := r.OK(); err != nil {
+	if err := r.OKFor(paymentType); err != nil {
 		return nil, nil, err
 	}
 	prq, err := json.Marshal(r)
@@ -180,6 +261,9 @@ func (a *API) payment(
 	if err != nil {
 		return nil, nil, err
 	}
+	if sca != nil && sca.Error.IsPaymentAuthError() {
+		sca.Cancel = a.paymentCanceler(sessionID, paymentType, sca.ID, opts...)
+	}
 	if sca != nil { //nolint:nestif
 		r := sca.Retry
 		sca.Retry = func(ctx context.Context, v interface{}) (*SCAHandler, error) {
@@ -188,6 +272,7 @@ func (a *API) payment(
 				return nil, err
 			}
 			if sca != nil && sca.Error.IsPaymentAuthError() {
+				sca.Cancel = a.paymentCanceler(sessionID, paymentType, sca.ID, opts...)
 				// Since this is a payment authorization request, the retry should be a call to CompletePayment.
 				sca.Retry = func(ctx context.Context, u interface{}) (*SCAHandler, error) {
 					v, sca, err := a.CompletePayment(ctx, sessionID, paymentType, sca.ID, opts...)
@@ -235,9 +320,47 @@ func (a *API) CompletePayment(
 	req := a.request(ctx, http.MethodPost, uri, nil, opts...)
 	pcr := &PaymentCreated{}
 	sca, err := a.do(req, http.StatusCreated, pcr)
+	if sca != nil && sca.Error.IsPaymentAuthError() {
+		sca.Cancel = a.paymentCanceler(sessionID, paymentType, paymentID, opts...)
+	}
 	return pcr, sca, err
 }
 
+// CancelPayment cancels an initiated payment which has not been completed yet.
+func (a *API) CancelPayment(
+	ctx context.Context,
+	sessionID string,
+	paymentType PaymentType,
+	paymentID string,
+	opts ...Optional,
+) error {
+	if sessionID == "" {
+		return ErrInvalidSessionID
+	}
+	if err := paymentType.OK(); err != nil {
+		return err
+	}
+	if paymentID == "" {
+		return ErrInvalidPaymentID
+	}
+	opts = append(opts, SessionID(sessionID))
+	uri := fmt.Sprintf("/ics/v3/payments/%s/%s", paymentType, paymentID)
+	req := a.request(ctx, http.MethodDelete, uri, nil, opts...)
+	_, err := a.do(req, http.StatusNoContent, nil)
+	return err
+}
+
+func (a *API) paymentCanceler(
+	sessionID string,
+	paymentType PaymentType,
+	paymentID string,
+	opts ...Optional,
+) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return a.CancelPayment(ctx, sessionID, paymentType, paymentID, opts...)
+	}
+}
+
 func (a *API) SEPAPayment(
 	ctx context.Context,
 	sessionID string,
@@ -303,8 +426,8 @@ func (a *API) AuthorizePayment(
 	if _, err := a.do(req, http.StatusOK, c); err != nil {
 		return nil, err
 	}
-	return &SCA{
-		URL: c.Links[0].Href,
-		ID:  c.PaymentID,
-	}, nil
+	if len(c.Links) == 0 {
+		return nil, ErrInvalidConsent
+	}
+	return scaFromConsent(c, nil), nil
 }
@@ -2,7 +2,11 @@ package neo_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"testing"
 
 	"github.com/enfunc/neo"
 )
@@ -18,8 +22,8 @@ func checkSandboxSEPA(ctx context.Context, api *neo.API, s *neo.Session, from, t
 			IBAN: to,
 		},
 		RemittanceInfoUnstructured: "test",
-		InstrumentedAmount:         "1.00",
-		Currency:                   "EUR",
+		InstrumentedAmount:         neo.MustParseAmount("1.00"),
+		Currency:                   neo.CurrencyEUR,
 		EndToEndIdentification:     "test",
 		PaymentMetadata: &neo.PaymentMetadata{
 			Address: &neo.Address{
@@ -58,8 +62,8 @@ func checkSandboxDomestic(ctx context.Context, api *neo.API, s *neo.Session, fro
 			BBAN: to,
 		},
 		RemittanceInfoUnstructured: "test",
-		InstrumentedAmount:         "1.00",
-		Currency:                   "NOK",
+		InstrumentedAmount:         neo.MustParseAmount("1.00"),
+		Currency:                   neo.CurrencyNOK,
 		EndToEndIdentification:     "test",
 		PaymentMetadata: &neo.PaymentMetadata{
 			Address: &neo.Address{
@@ -86,3 +90,69 @@ func checkSandboxDomestic(ctx context.Context, api *neo.API, s *neo.Session, fro
 	}
 	return nil
 }
+
+func TestPaymentRequestOK(t *testing.T) {
+	r := &neo.PaymentRequest{
+		DebtorName:                 "Debtor",
+		DebtorAccount:              &neo.AccountInfo{BBAN: "90412263056"},
+		CreditorName:               "Creditor",
+		CreditorAccount:            &neo.AccountInfo{BBAN: "90522037388"},
+		RemittanceInfoUnstructured: "test",
+		InstrumentedAmount:         neo.MustParseAmount("1.00"),
+		Currency:                   neo.CurrencyNOK,
+		EndToEndIdentification:     "test",
+	}
+	if err := r.OKFor(neo.PaymentTypeDomestic); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.OKFor(neo.PaymentTypeSEPA); !errors.Is(err, neo.ErrInvalidCurrency) {
+		t.Fatal("TestPaymentRequestOK: SEPA payments in NOK should be invalid")
+	}
+	r.InstrumentedAmount = neo.MustParseAmount("1.001")
+	if err := r.OK(); !errors.Is(err, neo.ErrInvalidAmount) {
+		t.Fatal("TestPaymentRequestOK: 1.001 NOK should be invalid")
+	}
+	r.InstrumentedAmount = neo.MustParseAmount("1")
+	r.DebtorAccount = &neo.AccountInfo{BBAN: "9041.22.63057", Country: "NO"}
+	if err := r.OK(); !errors.Is(err, neo.ErrInvalidBBAN) {
+		t.Fatal("TestPaymentRequestOK: 9041.22.63057 should be invalid")
+	}
+	r.DebtorAccount.BBAN = "9041.22.63056"
+	r.Currency = "nok"
+	if err := r.OKFor(neo.PaymentTypeDomestic); err != nil {
+		t.Fatalf("TestPaymentRequestOK: nok should be valid like NOK, got %v", err)
+	}
+	r.Currency = "KRONER"
+	if err := r.OK(); !errors.Is(err, neo.ErrInvalidCurrency) {
+		t.Fatal("TestPaymentRequestOK: KRONER should be invalid")
+	}
+}
+
+func TestPaymentRequestJSON(t *testing.T) {
+	r := &neo.PaymentRequest{
+		DebtorName:                 "Debtor",
+		DebtorAccount:              &neo.AccountInfo{IBAN: "NO93 8601 1117 947"},
+		CreditorName:               "Creditor",
+		CreditorAccount:            &neo.AccountInfo{IBAN: "NO83 3000 1234 567"},
+		InstrumentedAmount:         neo.NewAmount(125, 1),
+		Currency:                   "eur",
+		EndToEndIdentification:     "test",
+		RemittanceInfoUnstructured: "test",
+	}
+	if err := r.OKFor(neo.PaymentTypeSEPA); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"instrumentedAmount":"12.50"`) || strings.Count(string(b), "instrumentedAmount") != 1 {
+		t.Fatalf("TestPaymentRequestJSON: expected the amount in cents, got %s", b)
+	}
+	if !strings.Contains(string(b), `"iban":"NO9386011117947"`) {
+		t.Fatalf("TestPaymentRequestJSON: expected the normalized IBAN, got %s", b)
+	}
+	if !strings.Contains(string(b), `"currency":"EUR"`) || strings.Count(string(b), `"currency"`) != 1 {
+		t.Fatalf("TestPaymentRequestJSON: expected the normalized currency, got %s", b)
+	}
+}
@@ -0,0 +1,62 @@
+package recurring
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/enfunc/neo"
+)
+
+// processors are the payment services which prefix the name of the merchant, e.g. PAYPAL *SPOTIFY.
+var processors = []string{"vipps", "paypal", "sumup", "izettle", "zettle", "klarna", "sq", "google", "apple.com/bill"}
+
+// stopwords are the words which don't tell the counterparties apart: legal forms, top level domains,
+// currencies and the words banks add to card payments, e.g. *4321 02.03 NOK 129.00 NETFLIX.COM Kurs: 1.0000.
+var stopwords = map[string]bool{
+	"as": true, "asa": true, "ab": true, "publ": true, "oy": true, "oyj": true, "aps": true, "a": true, "s": true,
+	"ltd": true, "limited": true, "inc": true, "llc": true, "gmbh": true, "bv": true, "sa": true,
+	"www": true, "com": true, "no": true, "se": true, "dk": true, "fi": true, "net": true, "org": true, "eu": true,
+	"nok": true, "sek": true, "dkk": true, "isk": true, "eur": true, "usd": true, "gbp": true, "chf": true,
+	"kurs": true, "rate": true, "varekjøp": true, "kjøp": true, "køb": true, "köp": true, "visa": true, "debit": true,
+}
+
+// Normalize returns the key the transactions of a counterparty are grouped by, e.g. netflix for
+// both "NETFLIX.COM 866-579-7172" and "*4321 02.03 NOK 129.00 Netflix.com Kurs: 1.0000".
+// The words with digits, the common currency codes & the stopwords are left out.
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range processors {
+		if i := strings.Index(name, p+"*"); i >= 0 {
+			name = name[i+len(p)+1:]
+		} else if i := strings.Index(name, p+" *"); i >= 0 {
+			name = name[i+len(p)+2:]
+		}
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var key []string
+	for _, w := range words {
+		if stopwords[w] || strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+			continue
+		}
+		key = append(key, w)
+	}
+	return strings.Join(key, " ")
+}
+
+// counterpartyName returns the name of the other party of the transaction,
+// or the unstructured remittance info if the name is unknown, as is common for card payments.
+func counterpartyName(tx *neo.Tx) string {
+	name := tx.CounterpartyName
+	if name == "" {
+		name = tx.CreditorName
+		if tx.SignedAmount().Sign() > 0 {
+			name = tx.DebtorName
+		}
+	}
+	if name == "" {
+		name = tx.RemittanceInfoUnstructured
+	}
+	return name
+}
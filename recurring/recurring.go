@@ -0,0 +1,383 @@
+// Package recurring detects the recurring transactions of an account, such as subscriptions,
+// regular bills and salaries, and predicts when the next ones are due.
+//
+// The analysis is deterministic: the same transactions give the same series, whatever their order,
+// and it depends on no clock, so it can be tested on fixtures.
+package recurring
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+// The defaults of an Analyzer.
+const (
+	DefaultAmountTolerance = 0.2
+	DefaultMinOccurrences  = 3
+	DefaultMinConfidence   = 0.5
+)
+
+// Period is the interval between the transactions of a series.
+type Period string
+
+const (
+	Weekly    Period = "weekly"
+	Monthly   Period = "monthly"
+	Quarterly Period = "quarterly"
+	Yearly    Period = "yearly"
+)
+
+// periods are the intervals in days the periods allow between two transactions.
+var periods = []struct {
+	period   Period
+	min, max int
+}{
+	{Weekly, 5, 9},
+	{Monthly, 25, 35},
+	{Quarterly, 80, 102},
+	{Yearly, 340, 390},
+}
+
+// next returns the date one period after d. The monthly, quarterly & yearly dates fall on the given
+// day of the month, or on the last day of shorter months.
+func (p Period) next(d neo.Date, day int) neo.Date {
+	months := 0
+	switch p {
+	case Weekly:
+		return d.AddDays(7)
+	case Monthly:
+		months = 1
+	case Quarterly:
+		months = 3
+	case Yearly:
+		months = 12
+	}
+	first := neo.NewDate(d.Year(), d.Month()+time.Month(months), 1)
+	if last := neo.NewDate(first.Year(), first.Month()+1, 0).Day(); day > last {
+		day = last
+	}
+	return neo.NewDate(first.Year(), first.Month(), day)
+}
+
+// days returns the nominal length of the period in days.
+func (p Period) days() int {
+	switch p {
+	case Weekly:
+		return 7
+	case Monthly:
+		return 30
+	case Quarterly:
+		return 91
+	}
+	return 365
+}
+
+// Trend is the development of the amounts of a series.
+type Trend string
+
+const (
+	Stable     Trend = "stable"     // The amounts are within 1% of each other.
+	Increasing Trend = "increasing" // The amounts never decrease, e.g. after a price rise.
+	Decreasing Trend = "decreasing" // The amounts never increase.
+	Varying    Trend = "varying"    // The amounts go up & down, e.g. those of electricity bills.
+)
+
+// Series is a run of recurring transactions with the same counterparty.
+type Series struct {
+	Key          string // The normalized counterparty, see Normalize.
+	Counterparty string // The name of the counterparty, as reported with the latest transaction.
+	CreditDebit  neo.CreditDebit
+	Currency     neo.Currency
+	Period       Period
+	Txs          []*neo.Tx // In date order.
+	First, Last  neo.Date
+
+	// When the next transaction is expected.
+	Next neo.Date
+	// The expected absolute amount of the next transaction: the latest one, or the median if the amounts vary.
+	Amount neo.Amount
+	Trend  Trend
+	Change neo.Amount // The latest absolute amount minus the first one.
+
+	// How likely the series is recurring, from 0 to 1: the share of the intervals that fit the period,
+	// times (1 + the share of the amounts within the tolerance of the median) / 2,
+	// times n/(n+1) for n transactions.
+	Confidence float64
+	// Whether the series is expected to go on, i.e. its next transaction is at most half a period overdue.
+	Active bool
+}
+
+// Analyzer finds the recurring series among transactions.
+// The zero value is ready to use with the defaults.
+type Analyzer struct {
+	// How much the amount may differ from the previous one of a series, relative to it. Defaults to 0.2.
+	AmountTolerance float64
+	// The minimum number of transactions of a series. Defaults to 3, but yearly series need only 2,
+	// as few banks report more than a couple of years of history.
+	MinOccurrences int
+	// The minimum confidence of the series reported. Defaults to 0.5.
+	MinConfidence float64
+	// The date the series are active as of. Defaults to the latest date of the transactions.
+	AsOf neo.Date
+}
+
+// item is a transaction with what the analysis needs of it.
+type item struct {
+	tx     *neo.Tx
+	date   neo.Date
+	amount neo.Amount // Absolute.
+	value  float64    // The amount as a float, for the tolerances.
+	name   string
+}
+
+// Analyze returns the recurring series of the transactions, ordered by the next expected date.
+// Pending transactions & those without dates are left out.
+func (a *Analyzer) Analyze(txs []*neo.Tx) []*Series {
+	asOf := a.AsOf
+	groups := make(map[string][]*item)
+	var keys []string
+	for _, tx := range txs {
+		it, key, ok := newItem(tx)
+		if !ok {
+			continue
+		}
+		if _, seen := groups[key]; !seen {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], it)
+		if a.AsOf.IsZero() && it.date.After(asOf) {
+			asOf = it.date
+		}
+	}
+	sort.Strings(keys)
+	var series []*Series
+	for _, key := range keys {
+		items := groups[key]
+		sort.SliceStable(items, func(i, j int) bool { return items[i].less(items[j]) })
+		for _, cluster := range a.cluster(items) {
+			if s := a.series(cluster, asOf); s != nil {
+				series = append(series, s)
+			}
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		if c := series[i].Next.Compare(series[j].Next); c != 0 {
+			return c < 0
+		}
+		if series[i].Key != series[j].Key {
+			return series[i].Key < series[j].Key
+		}
+		return series[i].Amount.Cmp(series[j].Amount) < 0
+	})
+	return series
+}
+
+func newItem(tx *neo.Tx) (it *item, key string, ok bool) {
+	if tx == nil || tx.Status == neo.TxPending || tx.TransactionAmount == nil {
+		return nil, "", false
+	}
+	date := tx.BookingDate
+	if date == nil || date.IsZero() {
+		date = tx.ValueDate
+	}
+	if date == nil || date.IsZero() {
+		return nil, "", false
+	}
+	name := counterpartyName(tx)
+	normalized := Normalize(name)
+	if normalized == "" {
+		return nil, "", false
+	}
+	signed := tx.SignedAmount()
+	cd := neo.Credit
+	if signed.Sign() < 0 {
+		cd = neo.Debit
+	}
+	it = &item{tx: tx, date: *date, amount: signed.Abs(), value: float(signed.Abs()), name: name}
+	return it, normalized + "\x00" + string(cd) + "\x00" + string(tx.TransactionAmount.Currency), true
+}
+
+// less orders the items by date, then by ID, amount & hash, so that the order of the input doesn't matter.
+func (it *item) less(o *item) bool {
+	if c := it.date.Compare(o.date); c != 0 {
+		return c < 0
+	}
+	if it.tx.ID != o.tx.ID {
+		return it.tx.ID < o.tx.ID
+	}
+	if c := it.amount.Cmp(o.amount); c != 0 {
+		return c < 0
+	}
+	return it.tx.Hash() < o.tx.Hash()
+}
+
+// cluster splits the items of a counterparty by amount: each item joins the first cluster whose latest
+// amount is within the tolerance, so that the amounts may drift, e.g. with price rises.
+func (a *Analyzer) cluster(items []*item) [][]*item {
+	tolerance := a.AmountTolerance
+	if tolerance <= 0 {
+		tolerance = DefaultAmountTolerance
+	}
+	var clusters [][]*item
+	for _, it := range items {
+		joined := false
+		for i, c := range clusters {
+			latest := c[len(c)-1].value
+			if math.Abs(it.value-latest) <= tolerance*latest {
+				clusters[i], joined = append(c, it), true
+				break
+			}
+		}
+		if !joined {
+			clusters = append(clusters, []*item{it})
+		}
+	}
+	return clusters
+}
+
+// series detects the period of the cluster, returning nil if it isn't recurring.
+func (a *Analyzer) series(items []*item, asOf neo.Date) *Series { //nolint:cyclop
+	minOccurrences, minConfidence, tolerance := a.MinOccurrences, a.MinConfidence, a.AmountTolerance
+	if minOccurrences <= 0 {
+		minOccurrences = DefaultMinOccurrences
+	}
+	if minConfidence <= 0 {
+		minConfidence = DefaultMinConfidence
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultAmountTolerance
+	}
+	if len(items) < 2 {
+		return nil
+	}
+	intervals := make([]int, len(items)-1)
+	for i := range intervals {
+		intervals[i] = daysBetween(items[i].date, items[i+1].date)
+	}
+	sorted := append([]int{}, intervals...)
+	sort.Ints(sorted)
+	median := float64(sorted[len(sorted)/2])
+	if len(sorted)%2 == 0 {
+		median = float64(sorted[len(sorted)/2-1]+sorted[len(sorted)/2]) / 2
+	}
+	var period Period
+	var fit int
+	for _, p := range periods {
+		if median < float64(p.min) || median > float64(p.max) {
+			continue
+		}
+		period = p.period
+		for _, d := range intervals {
+			if d >= p.min && d <= p.max {
+				fit++
+			}
+		}
+	}
+	if period == "" || (len(items) < minOccurrences && !(period == Yearly && len(items) >= 2)) {
+		return nil
+	}
+
+	amounts := make([]neo.Amount, len(items))
+	for i, it := range items {
+		amounts[i] = it.amount
+	}
+	medianAmount := medianOf(amounts)
+	m, near := float(medianAmount), 0
+	for _, it := range items {
+		if math.Abs(it.value-m) <= tolerance*m {
+			near++
+		}
+	}
+	n := float64(len(items))
+	confidence := float64(fit) / float64(len(intervals)) * (1 + float64(near)/n) / 2 * n / (n + 1)
+	confidence = math.Round(confidence*1000) / 1000
+	if confidence < minConfidence {
+		return nil
+	}
+
+	first, last := items[0], items[len(items)-1]
+	s := &Series{
+		Counterparty: last.name,
+		CreditDebit:  last.tx.CreditDebitIndicator,
+		Currency:     last.tx.TransactionAmount.Currency,
+		Period:       period,
+		First:        first.date,
+		Last:         last.date,
+		Amount:       last.amount,
+		Trend:        trend(items),
+		Change:       last.amount.Sub(first.amount),
+		Confidence:   confidence,
+	}
+	s.Key = Normalize(s.Counterparty)
+	if s.CreditDebit == "" {
+		s.CreditDebit = neo.Credit
+		if last.tx.SignedAmount().Sign() < 0 {
+			s.CreditDebit = neo.Debit
+		}
+	}
+	if s.Trend == Varying {
+		s.Amount = medianAmount
+	}
+	for _, it := range items {
+		s.Txs = append(s.Txs, it.tx)
+	}
+	s.Next = period.next(last.date, typicalDay(items))
+	s.Active = daysBetween(s.Next, asOf) <= period.days()/2
+	return s
+}
+
+func trend(items []*item) Trend {
+	up, down := false, false
+	lo, hi := items[0].value, items[0].value
+	for i := 1; i < len(items); i++ {
+		switch c := items[i].amount.Cmp(items[i-1].amount); {
+		case c > 0:
+			up = true
+		case c < 0:
+			down = true
+		}
+		lo, hi = math.Min(lo, items[i].value), math.Max(hi, items[i].value)
+	}
+	switch {
+	case hi-lo <= 0.01*hi:
+		return Stable
+	case up && !down:
+		return Increasing
+	case down && !up:
+		return Decreasing
+	}
+	return Varying
+}
+
+// typicalDay returns the most common day of the month of the items, the latest one on a tie.
+func typicalDay(items []*item) int {
+	counts := make(map[int]int)
+	day := 0
+	for _, it := range items {
+		d := it.date.Day()
+		counts[d]++
+		if counts[d] >= counts[day] {
+			day = d
+		}
+	}
+	return day
+}
+
+func medianOf(amounts []neo.Amount) neo.Amount {
+	sorted := append([]neo.Amount{}, amounts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	return sorted[len(sorted)/2]
+}
+
+func daysBetween(from, to neo.Date) int {
+	return int(math.Round(to.In(time.UTC).Sub(from.In(time.UTC)).Hours() / 24))
+}
+
+func float(a neo.Amount) float64 {
+	f, _ := strconv.ParseFloat(a.String(), 64)
+	return f
+}
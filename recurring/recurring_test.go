@@ -0,0 +1,194 @@
+package recurring_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/enfunc/neo"
+	"github.com/enfunc/neo/recurring"
+)
+
+func tx(date, amount string, cd neo.CreditDebit, name string) *neo.Tx {
+	d := neo.MustParseDate(date)
+	return &neo.Tx{
+		ID:                   fmt.Sprintf("%s-%s-%s", date, name, amount),
+		TransactionAmount:    &neo.Money{Currency: neo.CurrencyNOK, Value: neo.MustParseAmount(amount)},
+		CreditDebitIndicator: cd,
+		Status:               neo.TxBooked,
+		BookingDate:          &d,
+		CounterpartyName:     name,
+	}
+}
+
+// card is a card payment, where the merchant is only named in the remittance info.
+func card(date, amount, merchant string) *neo.Tx {
+	t := tx(date, amount, neo.Debit, "")
+	t.RemittanceInfoUnstructured = fmt.Sprintf("*4321 %s NOK %s %s Kurs: 1.0000", date[8:]+"."+date[5:7], amount, merchant)
+	return t
+}
+
+// fixture is half a year of transactions of an account, as of 2022-06-30.
+func fixture() []*neo.Tx {
+	pending := card("2022-06-29", "129.00", "NETFLIX.COM")
+	pending.Status = neo.TxPending
+	return []*neo.Tx{
+		// A subscription with a price rise, reported with differing names.
+		card("2022-01-15", "109.00", "NETFLIX.COM"),
+		tx("2022-02-15", "109.00", neo.Debit, "NETFLIX.COM 866-579-7172"),
+		card("2022-03-15", "109.00", "Netflix.com"),
+		card("2022-04-15", "129.00", "NETFLIX.COM"),
+		tx("2022-05-16", "129.00", neo.Debit, "NETFLIX.COM 866-579-7172"),
+		card("2022-06-15", "129.00", "NETFLIX.COM"),
+		pending,
+
+		// The rent & the salary.
+		tx("2022-01-01", "12000.00", neo.Debit, "Utleier AS"),
+		tx("2022-02-01", "12000.00", neo.Debit, "Utleier AS"),
+		tx("2022-03-01", "12000.00", neo.Debit, "Utleier AS"),
+		tx("2022-04-01", "12000.00", neo.Debit, "Utleier AS"),
+		tx("2022-05-02", "12000.00", neo.Debit, "Utleier AS"),
+		tx("2022-06-01", "12000.00", neo.Debit, "Utleier AS"),
+		tx("2022-01-25", "32500.00", neo.Credit, "Arbeidsgiver AS"),
+		tx("2022-02-25", "32500.00", neo.Credit, "Arbeidsgiver AS"),
+		tx("2022-03-25", "32500.00", neo.Credit, "Arbeidsgiver AS"),
+		tx("2022-04-25", "32500.00", neo.Credit, "Arbeidsgiver AS"),
+		tx("2022-05-25", "35000.00", neo.Credit, "Arbeidsgiver AS"),
+		tx("2022-06-24", "35000.00", neo.Credit, "Arbeidsgiver AS"),
+
+		// An electricity bill, varying with the consumption.
+		tx("2022-01-20", "1000.00", neo.Debit, "Fjordkraft AS"),
+		tx("2022-02-21", "1150.00", neo.Debit, "Fjordkraft AS"),
+		tx("2022-03-19", "1000.00", neo.Debit, "Fjordkraft AS"),
+		tx("2022-04-20", "900.00", neo.Debit, "Fjordkraft AS"),
+		tx("2022-05-22", "1050.00", neo.Debit, "Fjordkraft AS"),
+		tx("2022-06-20", "980.00", neo.Debit, "Fjordkraft AS"),
+
+		// A weekly gym class, from May.
+		tx("2022-05-02", "99.00", neo.Debit, "SATS Norway AS"),
+		tx("2022-05-09", "99.00", neo.Debit, "SATS Norway AS"),
+		tx("2022-05-16", "99.00", neo.Debit, "SATS Norway AS"),
+		tx("2022-05-23", "99.00", neo.Debit, "SATS Norway AS"),
+		tx("2022-05-30", "99.00", neo.Debit, "SATS Norway AS"),
+		tx("2022-06-07", "99.00", neo.Debit, "SATS Norway AS"),
+		tx("2022-06-13", "99.00", neo.Debit, "SATS Norway AS"),
+		tx("2022-06-20", "99.00", neo.Debit, "SATS Norway AS"),
+
+		// A quarterly insurance premium & a yearly domain renewal.
+		tx("2021-07-10", "2400.00", neo.Debit, "Gjensidige Forsikring ASA"),
+		tx("2021-10-11", "2400.00", neo.Debit, "Gjensidige Forsikring ASA"),
+		tx("2022-01-10", "2400.00", neo.Debit, "Gjensidige Forsikring ASA"),
+		tx("2022-04-08", "2400.00", neo.Debit, "Gjensidige Forsikring ASA"),
+		tx("2020-09-01", "150.00", neo.Debit, "Domeneshop AS"),
+		tx("2021-09-01", "150.00", neo.Debit, "Domeneshop AS"),
+
+		// A subscription cancelled in March.
+		tx("2022-01-05", "149.00", neo.Debit, "Viaplay"),
+		tx("2022-02-05", "149.00", neo.Debit, "Viaplay"),
+		tx("2022-03-05", "149.00", neo.Debit, "Viaplay"),
+
+		// Groceries, which aren't recurring.
+		card("2022-01-03", "312.40", "REMA 1000 TORGGATA"),
+		card("2022-01-09", "54.90", "REMA 1000 TORGGATA"),
+		card("2022-02-14", "420.00", "REMA 1000 TORGGATA"),
+		card("2022-03-02", "61.20", "REMA 1000 TORGGATA"),
+		card("2022-03-27", "298.00", "REMA 1000 TORGGATA"),
+		card("2022-04-30", "64.50", "REMA 1000 TORGGATA"),
+	}
+}
+
+type summary struct {
+	Key         string
+	CreditDebit neo.CreditDebit
+	Period      recurring.Period
+	Next        string
+	Amount      string
+	Trend       recurring.Trend
+	Change      string
+	Active      bool
+	Txs         int
+}
+
+func summarize(series []*recurring.Series) []summary {
+	var s []summary
+	for _, r := range series {
+		s = append(s, summary{
+			Key:         r.Key,
+			CreditDebit: r.CreditDebit,
+			Period:      r.Period,
+			Next:        r.Next.String(),
+			Amount:      r.Amount.String(),
+			Trend:       r.Trend,
+			Change:      r.Change.String(),
+			Active:      r.Active,
+			Txs:         len(r.Txs),
+		})
+	}
+	return s
+}
+
+func TestAnalyze(t *testing.T) {
+	a := &recurring.Analyzer{}
+	series := a.Analyze(fixture())
+	expected := []summary{
+		{"viaplay", neo.Debit, recurring.Monthly, "2022-04-05", "149.00", recurring.Stable, "0.00", false, 3},
+		{"sats norway", neo.Debit, recurring.Weekly, "2022-06-27", "99.00", recurring.Stable, "0.00", true, 8},
+		{"utleier", neo.Debit, recurring.Monthly, "2022-07-01", "12000.00", recurring.Stable, "0.00", true, 6},
+		{"gjensidige forsikring", neo.Debit, recurring.Quarterly, "2022-07-10", "2400.00", recurring.Stable, "0.00", true, 4},
+		{"netflix", neo.Debit, recurring.Monthly, "2022-07-15", "129.00", recurring.Increasing, "20.00", true, 6},
+		{"fjordkraft", neo.Debit, recurring.Monthly, "2022-07-20", "1000.00", recurring.Varying, "-20.00", true, 6},
+		{"arbeidsgiver", neo.Credit, recurring.Monthly, "2022-07-25", "35000.00", recurring.Increasing, "2500.00", true, 6},
+		{"domeneshop", neo.Debit, recurring.Yearly, "2022-09-01", "150.00", recurring.Stable, "0.00", true, 2},
+	}
+	if got := summarize(series); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("unexpected series:\nexpected %+v\ngot      %+v", expected, got)
+	}
+	for _, s := range series {
+		if s.Confidence < recurring.DefaultMinConfidence || s.Confidence > 1 {
+			t.Fatalf("%s: unexpected confidence %v", s.Key, s.Confidence)
+		}
+	}
+	// The longer series are more certain.
+	confidence := make(map[string]float64)
+	for _, s := range series {
+		confidence[s.Key] = s.Confidence
+	}
+	if confidence["utleier"] <= confidence["viaplay"] || confidence["viaplay"] <= confidence["domeneshop"] {
+		t.Fatalf("unexpected confidences %v", confidence)
+	}
+
+	// The same transactions in any order give the same series.
+	txs := fixture()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5; i++ {
+		r.Shuffle(len(txs), func(i, j int) { txs[i], txs[j] = txs[j], txs[i] })
+		if shuffled := a.Analyze(txs); !reflect.DeepEqual(series, shuffled) {
+			t.Fatalf("the series depend on the order of the transactions:\n%+v", summarize(shuffled))
+		}
+	}
+
+	// A later date deactivates the series more than half a period overdue.
+	a.AsOf = neo.MustParseDate("2022-08-15")
+	for _, s := range a.Analyze(fixture()) {
+		if active := s.Key == "domeneshop" || s.Key == "gjensidige forsikring"; s.Active != active {
+			t.Fatalf("%s: expected active %v as of %s", s.Key, active, a.AsOf)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for name, want := range map[string]string{
+		"NETFLIX.COM 866-579-7172":                        "netflix",
+		"*4321 15.01 NOK 109.00 Netflix.com Kurs: 1.0000": "netflix",
+		"PAYPAL *SPOTIFY":                                 "spotify",
+		"Vipps*Kaffebar Østbanen":                         "kaffebar østbanen",
+		"Gjensidige Forsikring ASA":                       "gjensidige forsikring",
+		"Telia Sverige AB (publ)":                         "telia sverige",
+		"12345":                                           "",
+	} {
+		if got := recurring.Normalize(name); got != want {
+			t.Fatalf("%q: expected %q, got %q", name, want, got)
+		}
+	}
+}
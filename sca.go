@@ -1,18 +1,47 @@
 package neo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+)
+
+// SCAType describes how the end-user is expected to complete the SCA.
+type SCAType string
+
+const (
+	// SCARedirect requires the end-user to visit SCA.URL and consent there.
+	SCARedirect SCAType = "redirect"
+	// SCADecoupled is completed on another device (e.g. BankID on a phone).
+	// Poll the SCAHandler until the end-user confirms.
+	SCADecoupled SCAType = "decoupled"
+	// SCAEmbedded requires the end-user credentials or OTP to be submitted
+	// through API.SubmitSCA.
+	SCAEmbedded SCAType = "embedded"
+)
+
+// Link relations describing a non-redirect SCA flow.
+const (
+	LinkRelDecoupled = "decoupled"
+	LinkRelEmbedded  = "embedded"
 )
 
 type (
 	SCA struct {
-		URL   string // The URL the end-user has to visit and consent to.
-		ID    string // Metadata associated with the payload, either SessionID or PaymentID.
-		Error *Error // Error returned by the Neonomics platform.
+		Type    SCAType  // The kind of SCA flow, see SCAType.
+		URL     string   // The URL the end-user has to visit and consent to, or the URL embedded data is submitted to.
+		Method  string   // The HTTP method used to submit embedded data.
+		ID      string   // Metadata associated with the payload, either SessionID or PaymentID.
+		Message string   // Status message to be shown to the end-user, if any.
+		Fields  []string // Credentials the end-user has to provide in an embedded flow.
+		Error   *Error   // Error returned by the Neonomics platform.
+
+		IssuedAt time.Time     // When the SCA was issued.
+		TTL      time.Duration // How long the SCA stays valid after it was issued.
 	}
 
 	// SCAMapper maps the consent error into an SCA struct.
@@ -22,51 +51,176 @@ type (
 	SCAHandler struct {
 		*SCA
 		Retry func(ctx context.Context, v interface{}) (*SCAHandler, error)
+		// Cancel abandons the pending SCA flow, deleting the session or the initiated payment.
+		Cancel func(ctx context.Context) error
 	}
 )
 
-// DefaultSCAMapper maps a consent error into an SCA struct.
-func DefaultSCAMapper(d Doer, r *http.Request, e *Error) (*SCA, error) { //nolint:cyclop
-	if d == nil || r == nil || e == nil || len(e.Links) == 0 {
-		return nil, ErrInvalidSCAData
+// ExpiresAt returns the time after which the SCA is no longer valid.
+// It returns the zero time if the expiry is unknown.
+func (s *SCA) ExpiresAt() time.Time {
+	if s == nil || s.IssuedAt.IsZero() || s.TTL <= 0 {
+		return time.Time{}
 	}
-	fst := e.Links[0]
-	if !strings.Contains(fst.Href, "neonomics") {
-		return &SCA{
-			URL:   fst.Href,
-			ID:    fst.Meta.ID,
-			Error: e,
-		}, nil
+	return s.IssuedAt.Add(s.TTL)
+}
+
+// Expired reports whether the SCA is no longer valid, e.g. the consent URL is dead.
+func (s *SCA) Expired() bool {
+	exp := s.ExpiresAt()
+	return !exp.IsZero() && time.Now().After(exp)
+}
+
+// Poll retries the original request every interval for as long as the platform
+// responds with a decoupled SCA. It returns a nil handler once the end-user has confirmed,
+// or the next handler if a different kind of SCA is required.
+// Retrying doesn't extend the lifetime of the decoupled SCA, so polling stops with ErrSCAExpired once it expires.
+func (h *SCAHandler) Poll(ctx context.Context, interval time.Duration, v interface{}) (*SCAHandler, error) {
+	cur := h
+	for cur != nil && cur.SCA != nil && cur.Type == SCADecoupled {
+		if cur.Expired() {
+			return cur, ErrSCAExpired
+		}
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return cur, fmt.Errorf("neo: polling decoupled SCA: %w", ctx.Err())
+		case <-t.C:
+		}
+		next, err := cur.Retry(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		if next != nil && next.SCA != nil && next.Type == SCADecoupled {
+			// Still the same flow, reissued by the retry.
+			next.IssuedAt, next.TTL = cur.IssuedAt, cur.TTL
+		}
+		cur = next
 	}
-	req, err := http.NewRequestWithContext(r.Context(), fst.Type, fst.Href, nil)
-	if err != nil {
-		return nil, fmt.Errorf("neo: failed to create a new http.Request: %w", err)
+	return cur, nil
+}
+
+// SubmitSCA submits the end-user credentials or OTP for an embedded SCA.
+// If the bank requires another step (e.g. an OTP after the username), the next SCA is returned.
+// Once it returns a nil SCA, retry the original request using the SCAHandler.
+func (a *API) SubmitSCA(
+	ctx context.Context,
+	sessionID string,
+	sca *SCA,
+	data map[string]string,
+	opts ...Optional,
+) (*SCA, error) {
+	if sessionID == "" {
+		return nil, ErrInvalidSessionID
+	}
+	if sca == nil || sca.Type != SCAEmbedded || sca.URL == "" {
+		return nil, ErrInvalidSCAData
 	}
-	req.Header = r.Header  // Copy request headers.
-	resp, err := d.Do(req) //nolint:bodyclose
+	body, err := json.Marshal(data)
 	if err != nil {
-		return nil, fmt.Errorf("neo: failed to retrieve a SCA response: %w", err)
+		return nil, fmt.Errorf("neo: marshaling SCA data failed: %w", err)
 	}
-	defer closeBody(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("neo: invalid SCA response: %s", resp.Status)
+	method := sca.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	opts = append(opts, SessionID(sessionID))
+	req, err := a.newRequest(ctx, method, sca.URL, bytes.NewReader(body), opts...)
+	if err != nil {
+		return nil, err
 	}
 	c := &Consent{}
-	if err := json.NewDecoder(resp.Body).Decode(c); err != nil {
-		return nil, fmt.Errorf("neo: failed to decode consent: %w", err)
+	h, err := a.do(req, http.StatusOK, c)
+	if err != nil {
+		return nil, err
+	}
+	if h != nil {
+		return h.SCA, nil
 	}
 	if len(c.Links) == 0 {
-		return nil, ErrInvalidConsent
+		return nil, nil
 	}
+	return scaFromConsent(c, sca.Error), nil
+}
+
+// LinkPredicate reports whether the SCA details have to be fetched from the given link.
+type LinkPredicate func(l *Link) bool
+
+// IsNeonomicsLink reports whether the link points to the Neonomics platform.
+func IsNeonomicsLink(l *Link) bool {
+	return l != nil && strings.Contains(l.Href, "neonomics")
+}
+
+// DefaultSCAMapper maps a consent error into an SCA struct.
+// It fetches the SCA details from links pointing to the Neonomics platform.
+func DefaultSCAMapper(d Doer, r *http.Request, e *Error) (*SCA, error) {
+	return NewSCAMapper(IsNeonomicsLink)(d, r, e)
+}
+
+// NewSCAMapper creates an SCAMapper which fetches the SCA details from links matching fetch.
+// Any other link is used as the redirect URL as-is.
+func NewSCAMapper(fetch LinkPredicate) SCAMapper { //nolint:cyclop
+	return func(d Doer, r *http.Request, e *Error) (*SCA, error) {
+		if d == nil || r == nil || e == nil || len(e.Links) == 0 {
+			return nil, ErrInvalidSCAData
+		}
+		fst := e.Links[0]
+		if fetch == nil || !fetch(fst) {
+			return &SCA{
+				Type:  SCARedirect,
+				URL:   fst.Href,
+				ID:    fst.Meta.ID,
+				Error: e,
+			}, nil
+		}
+		req, err := http.NewRequestWithContext(r.Context(), fst.Type, fst.Href, nil)
+		if err != nil {
+			return nil, fmt.Errorf("neo: failed to create a new http.Request: %w", err)
+		}
+		req.Header = r.Header  // Copy request headers.
+		resp, err := d.Do(req) //nolint:bodyclose
+		if err != nil {
+			return nil, fmt.Errorf("neo: failed to retrieve a SCA response: %w", err)
+		}
+		defer closeBody(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("neo: invalid SCA response: %s", resp.Status)
+		}
+		c := &Consent{}
+		if err := json.NewDecoder(resp.Body).Decode(c); err != nil {
+			return nil, fmt.Errorf("neo: failed to decode consent: %w", err)
+		}
+		if len(c.Links) == 0 {
+			return nil, ErrInvalidConsent
+		}
+		return scaFromConsent(c, e), nil
+	}
+}
+
+// scaFromConsent detects the SCA flow described by the consent response.
+func scaFromConsent(c *Consent, e *Error) *SCA {
+	l := c.Links[0]
 	var id string
 	if c.PaymentID != "" {
 		id = c.PaymentID
 	} else {
-		id = c.Links[0].Meta.ID
+		id = l.Meta.ID
+	}
+	sca := &SCA{
+		Type:    SCARedirect,
+		URL:     l.Href,
+		ID:      id,
+		Message: c.Message,
+		Error:   e,
+	}
+	switch strings.ToLower(l.Rel) {
+	case LinkRelDecoupled:
+		sca.Type = SCADecoupled
+	case LinkRelEmbedded:
+		sca.Type = SCAEmbedded
+		sca.Method = l.Type
+		sca.Fields = l.Meta.Fields
 	}
-	return &SCA{
-		URL:   c.Links[0].Href,
-		ID:    id,
-		Error: e,
-	}, nil
+	return sca
 }
@@ -0,0 +1,223 @@
+package neo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+const consentError = `{"type":"CONSENT","errorCode":"1426","links":[{"type":"GET","href":"https://sandbox.neonomics.io/ics/v3/consent/session"}]}`
+
+// scaDoer responds to the account requests with a consent error until consented,
+// serving the given consent response from the Neonomics platform.
+func scaDoer(consent string, consented func() bool) doerFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.URL.Path == "/ics/v3/consent/session":
+			return response(http.StatusOK, consent, nil), nil
+		case consented():
+			return response(http.StatusOK, `[{"id":"acc-1"}]`, nil), nil
+		}
+		return response(510, consentError, nil), nil
+	}
+}
+
+func TestSCATypes(t *testing.T) {
+	for _, test := range []struct {
+		consent string
+		typ     neo.SCAType
+		method  string
+		fields  []string
+	}{
+		{
+			`{"links":[{"rel":"redirect","href":"https://bank.no/consent","meta":{"id":"session"}}]}`,
+			neo.SCARedirect, "", nil,
+		},
+		{
+			`{"message":"Open BankID","links":[{"rel":"Decoupled","href":"https://bank.no/status","meta":{"id":"session"}}]}`,
+			neo.SCADecoupled, "", nil,
+		},
+		{
+			`{"links":[{"rel":"embedded","type":"PUT","href":"https://sandbox.neonomics.io/ics/v3/consent/session/otp","meta":{"id":"session","fields":["otp"]}}]}`,
+			neo.SCAEmbedded, http.MethodPut, []string{"otp"},
+		},
+	} {
+		_, sca, err := fakeAPI(scaDoer(test.consent, func() bool { return false })).Accounts(context.TODO(), "session")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sca == nil || sca.Type != test.typ || sca.ID != "session" || sca.Method != test.method ||
+			len(sca.Fields) != len(test.fields) || (len(test.fields) > 0 && sca.Fields[0] != test.fields[0]) {
+			t.Fatalf("TestSCATypes: expected a %s SCA, got %+v", test.typ, sca)
+		}
+		if test.typ == neo.SCADecoupled && sca.Message != "Open BankID" {
+			t.Fatalf("TestSCATypes: missing the message, got %q", sca.Message)
+		}
+	}
+}
+
+func TestSCAPoll(t *testing.T) {
+	const decoupled = `{"message":"Open BankID","links":[{"rel":"decoupled","href":"https://bank.no/status","meta":{"id":"session"}}]}`
+	polls := 0
+	d := scaDoer(decoupled, func() bool {
+		polls++
+		return polls > 3
+	})
+	ctx := context.TODO()
+	accounts, sca, err := fakeAPI(d).Accounts(ctx, "session")
+	if err != nil || sca == nil || sca.Type != neo.SCADecoupled {
+		t.Fatalf("TestSCAPoll: expected a decoupled SCA, got %+v, %v", sca, err)
+	}
+	sca, err = sca.Poll(ctx, time.Millisecond, &accounts)
+	if err != nil || sca != nil || len(accounts) != 1 {
+		t.Fatalf("TestSCAPoll: expected the accounts once confirmed, got %+v, %v", sca, err)
+	}
+
+	// The end-user never confirms.
+	_, sca, err = fakeAPI(scaDoer(decoupled, func() bool { return false })).Accounts(ctx, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	next, err := sca.Poll(c, time.Millisecond, &accounts)
+	if !errors.Is(err, context.DeadlineExceeded) || next == nil || next.Type != neo.SCADecoupled {
+		t.Fatalf("TestSCAPoll: expected the pending SCA on cancellation, got %+v, %v", next, err)
+	}
+
+	// The end-user never confirms, and the SCA expires while polling.
+	api := fakeAPI(scaDoer(decoupled, func() bool { return false }))
+	api.SCATTL = 20 * time.Millisecond
+	if _, sca, err = api.Accounts(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+	c, cancel = context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	next, err = sca.Poll(c, time.Millisecond, &accounts)
+	if !errors.Is(err, neo.ErrSCAExpired) || next == nil || !next.IssuedAt.Equal(sca.IssuedAt) {
+		t.Fatalf("TestSCAPoll: expected ErrSCAExpired past the TTL, got %+v, %v", next, err)
+	}
+}
+
+func TestSubmitSCA(t *testing.T) {
+	var submitted map[string]string
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method != http.MethodPut || r.URL.Path != "/ics/v3/consent/session/otp" || r.Header.Get(neo.HeaderSessionID) != "session" {
+			t.Errorf("TestSubmitSCA: unexpected request %s %s", r.Method, r.URL)
+		}
+		submitted = nil
+		if err := json.NewDecoder(r.Body).Decode(&submitted); err != nil {
+			t.Error(err)
+		}
+		if submitted["username"] != "" {
+			// The OTP is requested after the username.
+			return response(http.StatusOK, `{"links":[{"rel":"embedded","type":"PUT","href":"https://sandbox.neonomics.io/ics/v3/consent/session/otp","meta":{"fields":["otp"]}}]}`, nil), nil
+		}
+		return response(http.StatusOK, `{}`, nil), nil
+	})
+	api := fakeAPI(d)
+	ctx := context.TODO()
+	sca := &neo.SCA{Type: neo.SCAEmbedded, URL: "https://sandbox.neonomics.io/ics/v3/consent/session/otp", Method: http.MethodPut}
+
+	next, err := api.SubmitSCA(ctx, "session", sca, map[string]string{"username": "knut"})
+	if err != nil || next == nil || next.Type != neo.SCAEmbedded || len(next.Fields) != 1 || next.Fields[0] != "otp" {
+		t.Fatalf("TestSubmitSCA: expected the OTP step, got %+v, %v", next, err)
+	}
+	next, err = api.SubmitSCA(ctx, "session", next, map[string]string{"otp": "123456"})
+	if err != nil || next != nil || submitted["otp"] != "123456" {
+		t.Fatalf("TestSubmitSCA: expected the SCA to be done, got %+v, %v", next, err)
+	}
+
+	if _, err := api.SubmitSCA(ctx, "session", &neo.SCA{Type: neo.SCARedirect, URL: "https://bank.no"}, nil); !errors.Is(err, neo.ErrInvalidSCAData) {
+		t.Fatalf("TestSubmitSCA: expected ErrInvalidSCAData, got %v", err)
+	}
+	if _, err := api.SubmitSCA(ctx, "", sca, nil); !errors.Is(err, neo.ErrInvalidSessionID) {
+		t.Fatalf("TestSubmitSCA: expected ErrInvalidSessionID, got %v", err)
+	}
+}
+
+func TestSCAExpiry(t *testing.T) {
+	const redirect = `{"links":[{"href":"https://bank.no/consent","meta":{"id":"session"}}]}`
+	api := fakeAPI(scaDoer(redirect, func() bool { return false }))
+	api.SCATTL = time.Minute
+	_, sca, err := api.Accounts(context.TODO(), "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sca.TTL != time.Minute || sca.Expired() || !sca.ExpiresAt().Equal(sca.IssuedAt.Add(time.Minute)) {
+		t.Fatalf("TestSCAExpiry: expected a valid SCA with API.SCATTL, got %+v", sca.SCA)
+	}
+	sca.IssuedAt = time.Now().Add(-2 * time.Minute)
+	if !sca.Expired() {
+		t.Fatal("TestSCAExpiry: expected the SCA to be expired")
+	}
+	if (&neo.SCA{}).Expired() || !(&neo.SCA{}).ExpiresAt().IsZero() {
+		t.Fatal("TestSCAExpiry: an SCA of unknown expiry should never expire")
+	}
+
+	// A TTL set by the mapper takes precedence.
+	api.Mapper = func(d neo.Doer, r *http.Request, e *neo.Error) (*neo.SCA, error) {
+		sca, err := neo.DefaultSCAMapper(d, r, e)
+		if err == nil {
+			sca.TTL = time.Hour
+		}
+		return sca, err
+	}
+	if _, sca, err = api.Accounts(context.TODO(), "session"); err != nil || sca.TTL != time.Hour {
+		t.Fatalf("TestSCAExpiry: expected the TTL of the mapper, got %+v, %v", sca, err)
+	}
+
+	// Polling an expired decoupled SCA stops right away.
+	sca.Type, sca.IssuedAt = neo.SCADecoupled, time.Now().Add(-2*time.Hour)
+	if _, err := sca.Poll(context.TODO(), time.Millisecond, nil); !errors.Is(err, neo.ErrSCAExpired) {
+		t.Fatalf("TestSCAExpiry: expected ErrSCAExpired, got %v", err)
+	}
+}
+
+func TestSCACancel(t *testing.T) {
+	var deleted []string
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			return response(http.StatusNoContent, ``, nil), nil
+		case r.URL.Path == "/ics/v3/payments/domestic-transfer":
+			return response(510, `{"type":"CONSENT","errorCode":"1428","links":[{"href":"https://bank.no/authorize","meta":{"id":"payment-1"}}]}`, nil), nil
+		}
+		return response(510, `{"type":"CONSENT","errorCode":"1426","links":[{"href":"https://bank.no/consent","meta":{"id":"session"}}]}`, nil), nil
+	})
+	api := fakeAPI(d)
+	ctx := context.TODO()
+
+	_, sca, err := api.Accounts(ctx, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sca.Cancel(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, sca, err = api.DomesticPayment(ctx, "session", &neo.PaymentRequest{
+		DebtorName:                 "Debtor",
+		DebtorAccount:              &neo.AccountInfo{BBAN: "90412263056"},
+		CreditorName:               "Creditor",
+		CreditorAccount:            &neo.AccountInfo{BBAN: "90522037388"},
+		RemittanceInfoUnstructured: "test",
+		InstrumentedAmount:         neo.MustParseAmount("1.00"),
+		Currency:                   neo.CurrencyNOK,
+		EndToEndIdentification:     "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sca.Cancel(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 2 || deleted[0] != "/ics/v3/session/session" || deleted[1] != "/ics/v3/payments/domestic-transfer/payment-1" {
+		t.Fatalf("TestSCACancel: expected the session & the payment to be deleted, got %v", deleted)
+	}
+}
@@ -2,9 +2,13 @@ package neo
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Session struct {
@@ -24,10 +28,51 @@ func (a *API) NewSession(ctx context.Context, bankID string) (*Session, error) {
 }
 
 type SessionStatus struct {
-	BankID     string `json:"bankId"`
-	BankName   string `json:"bankName"`
-	CreatedAt  string `json:"createdAt"`
-	ProviderID string `json:"providerId"`
+	BankID     string    `json:"bankId"`
+	BankName   string    `json:"bankName"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ProviderID string    `json:"providerId"`
+}
+
+func (s *SessionStatus) UnmarshalJSON(data []byte) error {
+	type status SessionStatus
+	aux := &struct {
+		*status
+		CreatedAt json.RawMessage `json:"createdAt"`
+	}{status: (*status)(s)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return fmt.Errorf("unable to unmarshal session status: %w", err)
+	}
+	raw := strings.Trim(string(aux.CreatedAt), `"`)
+	if raw == "" || raw == "null" {
+		s.CreatedAt = time.Time{}
+		return nil
+	}
+	t, ok := parseTimestamp(raw)
+	if !ok {
+		return fmt.Errorf("unable to unmarshal session creation time %s", aux.CreatedAt)
+	}
+	s.CreatedAt = t
+	return nil
+}
+
+// parseTimestamp parses the timestamps returned by the platform,
+// either as a string or as milliseconds since the Unix epoch.
+func parseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), true
+	}
+	return time.Time{}, false
 }
 
 // Status returns the details of the given session.
@@ -41,6 +86,66 @@ func (a *API) Status(ctx context.Context, sessionID string) (*SessionStatus, err
 	return s, err
 }
 
+// SessionState describes whether a session can be used to access the end-user data.
+type SessionState string
+
+const (
+	SessionAlive           SessionState = "alive"
+	SessionConsentRequired SessionState = "consent-required"
+	SessionExpired         SessionState = "expired"
+)
+
+type SessionHealth struct {
+	State  SessionState
+	Status *SessionStatus // The session details, unless the session has expired.
+	Error  *Error         // The platform error which caused the state, if any.
+}
+
+// ConsentState maps the session health to the consent state of a stored session.
+func (h *SessionHealth) ConsentState() ConsentState {
+	switch h.State {
+	case SessionAlive:
+		return ConsentGranted
+	case SessionConsentRequired:
+		return ConsentRequired
+	case SessionExpired:
+		return ConsentExpired
+	}
+	return ConsentUnknown
+}
+
+// SessionHealth checks whether the given session is alive and holds a valid consent.
+// Since the session status doesn't tell the latter, it lists the accounts without triggering an SCA.
+// This is no lightweight probe: every call reaches the bank, counting against its rate limits and,
+// for many banks, against the 4 daily accesses allowed without the end-user present.
+// Use Status to only check that the session exists.
+func (a *API) SessionHealth(ctx context.Context, sessionID string, opts ...Optional) (*SessionHealth, error) {
+	stat, err := a.Status(ctx, sessionID)
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) && e.IsSessionError() {
+			return &SessionHealth{State: SessionExpired, Error: e}, nil
+		}
+		return nil, err
+	}
+	opts = append(opts, SessionID(sessionID))
+	req := a.request(ctx, http.MethodGet, "/ics/v3/accounts", nil, opts...)
+	_, err = a.send(req, http.StatusOK, nil, nil)
+	var e *Error
+	switch {
+	case err == nil:
+		return &SessionHealth{State: SessionAlive, Status: stat}, nil
+	case !errors.As(err, &e):
+		return nil, err
+	case e.IsConsentError():
+		return &SessionHealth{State: SessionConsentRequired, Status: stat, Error: e}, nil
+	case e.IsSessionError():
+		return &SessionHealth{State: SessionExpired, Error: e}, nil
+	default:
+		return nil, err
+	}
+}
+
 // DeleteSession deletes & invalidates a given session.
 func (a *API) DeleteSession(ctx context.Context, sessionID string) error {
 	if sessionID == "" {
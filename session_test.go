@@ -2,7 +2,14 @@ package neo_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
 
 	"github.com/enfunc/neo"
 )
@@ -19,9 +26,257 @@ func checkSandboxSession(ctx context.Context, api *neo.API, bankID string) (*neo
 	if bankID != stat.BankID {
 		return nil, fmt.Errorf("checkSandboxSession: %s != %s", bankID, stat.BankID)
 	}
+	if stat.CreatedAt.IsZero() {
+		return nil, errors.New("checkSandboxSession: missing creation time")
+	}
+	health, err := api.SessionHealth(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	if health.State == neo.SessionExpired {
+		return nil, errors.New("checkSandboxSession: new session expired")
+	}
 	err = api.DeleteSession(ctx, s.ID)
 	if err != nil {
 		return nil, err
 	}
 	return api.NewSession(ctx, bankID)
 }
+
+func TestSandboxSessionManager(t *testing.T) {
+	ctx := context.TODO()
+	m := neo.NewSessionManager(sandboxAPI(ctx), nil)
+	fst, err := m.Session(ctx, bankDNB, "test-psu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snd, err := m.Session(ctx, bankDNB, "test-psu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fst.ID != snd.ID {
+		t.Fatalf("TestSandboxSessionManager: %s != %s", fst.ID, snd.ID)
+	}
+	if err := m.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionStatusCreatedAt(t *testing.T) {
+	want := time.Date(2022, 3, 1, 12, 30, 0, 0, time.UTC)
+	for _, raw := range []string{
+		`{"createdAt":"2022-03-01T12:30:00Z"}`,
+		`{"createdAt":"2022-03-01T12:30:00.000"}`,
+		`{"createdAt":1646137800000}`,
+	} {
+		s := &neo.SessionStatus{}
+		if err := json.Unmarshal([]byte(raw), s); err != nil {
+			t.Fatal(err)
+		}
+		if !s.CreatedAt.Equal(want) {
+			t.Fatalf("TestSessionStatusCreatedAt: %s != %s", s.CreatedAt, want)
+		}
+	}
+}
+
+// sessionDoer fakes the session endpoints, reporting the sessions as created at the given time.
+// It returns the IDs of the deleted sessions through the returned func.
+func sessionDoer(createdAt time.Time, block func(bankID string)) (doerFunc, func() []string) {
+	var mu sync.Mutex
+	var n int
+	var deleted []string
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.Method {
+		case http.MethodPost:
+			b := struct{ BankID string }{}
+			if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+				return nil, err
+			}
+			block(b.BankID)
+			mu.Lock()
+			defer mu.Unlock()
+			n++
+			return response(http.StatusCreated, fmt.Sprintf(`{"sessionId":"s-%d"}`, n), nil), nil
+		case http.MethodDelete:
+			mu.Lock()
+			defer mu.Unlock()
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/ics/v3/session/"))
+			return response(http.StatusNoContent, ``, nil), nil
+		}
+		return response(http.StatusOK, fmt.Sprintf(`{"createdAt":%q}`, createdAt.Format(time.RFC3339)), nil), nil
+	})
+	return d, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, deleted...)
+	}
+}
+
+func TestSessionManager(t *testing.T) {
+	ctx := context.TODO()
+	d, deleted := sessionDoer(time.Now(), func(string) {})
+	m := neo.NewSessionManager(fakeAPI(d), nil)
+	fst, err := m.Session(ctx, "bank", "psu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snd, err := m.Session(ctx, "bank", "psu"); err != nil || snd.ID != fst.ID {
+		t.Fatalf("TestSessionManager: expected %s to be reused, got %v, %v", fst.ID, snd, err)
+	}
+	if other, err := m.Session(ctx, "bank", "other-psu"); err != nil || other.ID == fst.ID {
+		t.Fatalf("TestSessionManager: expected a session per PSU, got %v, %v", other, err)
+	}
+
+	// The platform reports the session as expired.
+	var ids []string
+	err = m.Do(ctx, "bank", "psu", func(s *neo.Session) error {
+		ids = append(ids, s.ID)
+		if len(ids) == 1 {
+			return &neo.Error{Type: "SESSION", ErrorCode: "1001", Message: "session expired"}
+		}
+		return nil
+	})
+	if err != nil || len(ids) != 2 || ids[0] != fst.ID || ids[1] == fst.ID {
+		t.Fatalf("TestSessionManager: expected the session to be recreated, got %v, %v", ids, err)
+	}
+	if s, err := m.Session(ctx, "bank", "psu"); err != nil || s.ID != ids[1] {
+		t.Fatalf("TestSessionManager: expected the recreated session %s, got %v, %v", ids[1], s, err)
+	}
+	m.IdleTimeout = time.Hour
+	if err := m.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := deleted(); len(got) != 0 {
+		t.Fatalf("TestSessionManager: expected the live sessions to be kept, got %v", got)
+	}
+	m.IdleTimeout = time.Nanosecond
+	if err := m.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := deleted(); len(got) != 2 {
+		t.Fatalf("TestSessionManager: expected the idle sessions to be deleted, got %v", got)
+	}
+}
+
+// goneStore reports every deleted session as not found, as if it had been deleted concurrently.
+type goneStore struct {
+	*neo.MemorySessionStore
+}
+
+func (s goneStore) Delete(ctx context.Context, id string) error {
+	_ = s.MemorySessionStore.Delete(ctx, id)
+	return neo.ErrSessionNotFound
+}
+
+func TestSessionManagerDeleted(t *testing.T) {
+	ctx := context.TODO()
+	d, _ := sessionDoer(time.Now(), func(string) {})
+	m := neo.NewSessionManager(fakeAPI(d), goneStore{neo.NewMemorySessionStore()})
+	var ids []string
+	err := m.Do(ctx, "bank", "psu", func(s *neo.Session) error {
+		ids = append(ids, s.ID)
+		if len(ids) == 1 {
+			return &neo.Error{Type: "SESSION", ErrorCode: "1001", Message: "session expired"}
+		}
+		return nil
+	})
+	if err != nil || len(ids) != 2 || ids[0] == ids[1] {
+		t.Fatalf("TestSessionManagerDeleted: expected the session to be recreated, got %v, %v", ids, err)
+	}
+}
+
+func TestSessionManagerTTL(t *testing.T) {
+	ctx := context.TODO()
+	d, deleted := sessionDoer(time.Now().Add(-2*time.Hour), func(string) {})
+	m := neo.NewSessionManager(fakeAPI(d), nil)
+	m.TTL = time.Hour
+	fst, err := m.Session(ctx, "bank", "psu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snd, err := m.Session(ctx, "bank", "psu")
+	if err != nil || snd.ID == fst.ID {
+		t.Fatalf("TestSessionManagerTTL: expected a new session, got %v, %v", snd, err)
+	}
+	if got := deleted(); len(got) != 1 || got[0] != fst.ID {
+		t.Fatalf("TestSessionManagerTTL: expected %s to be deleted, got %v", fst.ID, got)
+	}
+}
+
+func TestSessionManagerConcurrency(t *testing.T) {
+	ctx := context.TODO()
+	blocked, release := make(chan struct{}), make(chan struct{})
+	d, _ := sessionDoer(time.Now(), func(bankID string) {
+		if bankID == "slow" {
+			close(blocked)
+			<-release
+		}
+	})
+	m := neo.NewSessionManager(fakeAPI(d), nil)
+	slow := make(chan error)
+	go func() {
+		_, err := m.Session(ctx, "slow", "psu")
+		slow <- err
+	}()
+	<-blocked
+
+	// A slow bank holds up neither the other banks nor sweeping their sessions.
+	fast := make(chan error)
+	go func() {
+		_, err := m.Session(ctx, "fast", "psu")
+		if err == nil {
+			err = m.Invalidate(ctx, "fast", "psu")
+		}
+		fast <- err
+	}()
+	select {
+	case err := <-fast:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("TestSessionManagerConcurrency: blocked by the slow bank")
+	}
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionHealth(t *testing.T) {
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		id := r.Header.Get(neo.HeaderSessionID)
+		switch {
+		case r.URL.Path == "/ics/v3/session/expired":
+			return response(520, `{"type":"SESSION","errorCode":"1001"}`, nil), nil
+		case strings.HasPrefix(r.URL.Path, "/ics/v3/session/"):
+			return response(http.StatusOK, `{"bankId":"bank"}`, nil), nil
+		case id == "consent":
+			return response(510, consentError, nil), nil
+		case id == "gone":
+			return response(520, `{"type":"SESSION","errorCode":"1001"}`, nil), nil
+		case id == "down":
+			return response(http.StatusInternalServerError, `{}`, nil), nil
+		}
+		return response(http.StatusOK, `[]`, nil), nil
+	})
+	api := fakeAPI(d)
+	for id, want := range map[string]neo.SessionState{
+		"alive":   neo.SessionAlive,
+		"consent": neo.SessionConsentRequired,
+		"expired": neo.SessionExpired,
+		"gone":    neo.SessionExpired,
+	} {
+		h, err := api.SessionHealth(context.TODO(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.State != want || (want != neo.SessionAlive && h.Error == nil) {
+			t.Fatalf("TestSessionHealth: %s: expected %s, got %+v", id, want, h)
+		}
+	}
+	if _, err := api.SessionHealth(context.TODO(), "down"); err == nil {
+		t.Fatal("TestSessionHealth: expected the error of the bank")
+	}
+}
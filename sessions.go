@@ -0,0 +1,258 @@
+package neo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SessionManager reuses sessions per bank & end-user, recreating them once expired
+// and deleting the ones which are no longer in use.
+type SessionManager struct {
+	API         *API
+	Store       SessionStore  // Where the sessions are kept.
+	TTL         time.Duration // Maximum session age, measured from SessionStatus.CreatedAt. Zero disables the check.
+	IdleTimeout time.Duration // Sessions unused for longer than this are deleted by Sweep. Zero disables the check.
+
+	mu    sync.Mutex
+	locks map[string]*keyLock // Per bank & PSU, so that a slow bank doesn't hold up the others.
+}
+
+// keyLock serializes the calls for a bank & PSU, counting its users so it's dropped once unused.
+type keyLock struct {
+	sync.Mutex
+	users int
+}
+
+// lock locks the sessions of the given bank ID & PSU, returning the func unlocking them.
+func (m *SessionManager) lock(bankID, psu string) func() {
+	key := bankID + "\x00" + psu
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyLock)
+	}
+	l := m.locks[key]
+	if l == nil {
+		l = &keyLock{}
+		m.locks[key] = l
+	}
+	l.users++
+	m.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		if l.users--; l.users == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// NewSessionManager creates a session manager on top of the given API.
+// If store is nil, the sessions are kept in memory.
+func NewSessionManager(api *API, store SessionStore) *SessionManager {
+	if store == nil {
+		store = NewMemorySessionStore()
+	}
+	return &SessionManager{
+		API:   api,
+		Store: store,
+	}
+}
+
+// Session returns a live session for the given bank ID & end-user reference (PSU),
+// creating a new one if there is none or the previous one has expired.
+func (m *SessionManager) Session(ctx context.Context, bankID, psu string) (*Session, error) {
+	if bankID == "" {
+		return nil, ErrInvalidBankID
+	}
+	defer m.lock(bankID, psu)()
+	s, err := m.find(ctx, bankID, psu)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if s != nil && m.TTL > 0 && now.Sub(s.CreatedAt) >= m.TTL {
+		if err := m.Store.Delete(ctx, s.ID); err != nil {
+			return nil, err
+		}
+		_ = m.API.DeleteSession(ctx, s.ID)
+		s = nil
+	}
+	if s == nil {
+		if s, err = m.create(ctx, bankID, psu); err != nil {
+			return nil, err
+		}
+	}
+	s.LastUsedAt = now
+	if err := m.Store.Put(ctx, s); err != nil {
+		return nil, err
+	}
+	return &Session{ID: s.ID}, nil
+}
+
+// find returns the most recent stored session for the given bank ID & PSU, if any.
+func (m *SessionManager) find(ctx context.Context, bankID, psu string) (*StoredSession, error) {
+	ss, err := m.Store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found *StoredSession
+	for _, s := range ss {
+		if s.BankID == bankID && s.PSU == psu && (found == nil || s.CreatedAt.After(found.CreatedAt)) {
+			found = s
+		}
+	}
+	return found, nil
+}
+
+func (m *SessionManager) create(ctx context.Context, bankID, psu string) (*StoredSession, error) {
+	s, err := m.API.NewSession(ctx, bankID)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := m.API.Status(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	createdAt := stat.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return &StoredSession{
+		ID:        s.ID,
+		BankID:    bankID,
+		PSU:       psu,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// Do calls fn with a live session for the given bank ID & end-user reference (PSU).
+// If the platform reports the session as expired, the session is recreated and fn is called once more.
+func (m *SessionManager) Do(ctx context.Context, bankID, psu string, fn func(s *Session) error) error {
+	s, err := m.Session(ctx, bankID, psu)
+	if err != nil {
+		return err
+	}
+	err = fn(s)
+	if !IsSessionExpired(err) {
+		return err
+	}
+	if err := m.forget(ctx, bankID, psu, s.ID); err != nil {
+		return err
+	}
+	if s, err = m.Session(ctx, bankID, psu); err != nil {
+		return err
+	}
+	return fn(s)
+}
+
+// forget removes the expired session from the store once its bank & PSU are locked.
+// A session deleted in the meantime, e.g. by Sweep, is not an error.
+func (m *SessionManager) forget(ctx context.Context, bankID, psu, id string) error {
+	defer m.lock(bankID, psu)()
+	if err := m.Store.Delete(ctx, id); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	return nil
+}
+
+// IsSessionExpired reports whether err was caused by an expired or unknown session.
+func IsSessionExpired(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.IsSessionError()
+}
+
+// Invalidate deletes the session of the given bank ID & end-user reference (PSU), if any.
+func (m *SessionManager) Invalidate(ctx context.Context, bankID, psu string) error {
+	return m.deleteWhere(ctx, func(s *StoredSession) bool {
+		return s.BankID == bankID && s.PSU == psu
+	})
+}
+
+// Sweep deletes the sessions which have been idle for longer than IdleTimeout or are older than TTL.
+func (m *SessionManager) Sweep(ctx context.Context) error {
+	now := time.Now()
+	return m.deleteWhere(ctx, func(s *StoredSession) bool {
+		idle := m.IdleTimeout > 0 && now.Sub(s.LastUsedAt) >= m.IdleTimeout
+		old := m.TTL > 0 && now.Sub(s.CreatedAt) >= m.TTL
+		return idle || old
+	})
+}
+
+// Run sweeps the sessions every interval until the context is done.
+func (m *SessionManager) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			_ = m.Sweep(ctx)
+		}
+	}
+}
+
+// Close sweeps the sessions one last time. The live ones are kept, as the store may be shared
+// with other processes, and so that they're reused after a restart.
+func (m *SessionManager) Close(ctx context.Context) error {
+	return m.Sweep(ctx)
+}
+
+// update applies fn to the stored session once its bank & PSU are locked, returning the updated session,
+// or nil if it has been deleted in the meantime.
+func (m *SessionManager) update(ctx context.Context, s *StoredSession, fn func(s *StoredSession)) (*StoredSession, error) {
+	defer m.lock(s.BankID, s.PSU)()
+	s, err := m.Store.Get(ctx, s.ID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fn(s)
+	return s, m.Store.Put(ctx, s)
+}
+
+// deleteWhere deletes the matching sessions, returning the first error encountered.
+func (m *SessionManager) deleteWhere(ctx context.Context, match func(s *StoredSession) bool) error {
+	ss, err := m.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, s := range ss {
+		if !match(s) {
+			continue
+		}
+		if err := m.delete(ctx, s, match); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// delete deletes the session if it still matches once its bank & PSU are locked,
+// as it may have been used in the meantime.
+func (m *SessionManager) delete(ctx context.Context, s *StoredSession, match func(s *StoredSession) bool) error {
+	defer m.lock(s.BankID, s.PSU)()
+	s, err := m.Store.Get(ctx, s.ID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !match(s) {
+		return nil
+	}
+	err = m.Store.Delete(ctx, s.ID)
+	if e := m.API.DeleteSession(ctx, s.ID); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,269 @@
+package neo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConsentState describes whether a session holds a valid end-user consent.
+type ConsentState string
+
+const (
+	ConsentUnknown  ConsentState = ""
+	ConsentRequired ConsentState = "required"
+	ConsentGranted  ConsentState = "granted"
+	ConsentExpired  ConsentState = "expired"
+)
+
+// StoredSession is a persisted session along with its metadata.
+type StoredSession struct {
+	ID         string       `json:"id"`
+	BankID     string       `json:"bankId"`
+	PSU        string       `json:"psu"` // The end-user reference the session belongs to.
+	CreatedAt  time.Time    `json:"createdAt"`
+	LastUsedAt time.Time    `json:"lastUsedAt"`
+	Consent    ConsentState `json:"consent"`
+
+	ConsentExpiresAt time.Time `json:"consentExpiresAt,omitempty"` // When the granted consent expires, if known.
+}
+
+// SessionStore persists sessions, so they survive restarts and can be shared between processes.
+type SessionStore interface {
+	// Put creates or replaces the session with the same ID.
+	Put(ctx context.Context, s *StoredSession) error
+	// Get returns the session with the given ID, or ErrSessionNotFound.
+	Get(ctx context.Context, id string) (*StoredSession, error)
+	// List returns all stored sessions.
+	List(ctx context.Context) ([]*StoredSession, error)
+	// Delete removes the session with the given ID. Deleting an unknown session is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemorySessionStore keeps the sessions in memory.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]StoredSession
+}
+
+// NewMemorySessionStore creates an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]StoredSession),
+	}
+}
+
+func (m *MemorySessionStore) Put(_ context.Context, s *StoredSession) error {
+	if s == nil || s.ID == "" {
+		return ErrInvalidSessionID
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+func (m *MemorySessionStore) Get(_ context.Context, id string) (*StoredSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &s, nil
+}
+
+func (m *MemorySessionStore) List(context.Context) ([]*StoredSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ss := make([]*StoredSession, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		s := s
+		ss = append(ss, &s)
+	}
+	sortSessions(ss)
+	return ss, nil
+}
+
+func (m *MemorySessionStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+// FileSessionStore keeps the sessions in a JSON-lines file, one session per line.
+// Every write replaces the file atomically, and a lock file guards against concurrent writers,
+// so the same file can be shared between processes on the same machine.
+// The lock is released by the OS if a process crashes, except on the platforms without file locks
+// (neither Unix nor Windows), where a lock file left by a crashed process has to be removed by hand.
+type FileSessionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSessionStore creates a session store backed by the file at the given path.
+// The file is created on the first write.
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{path: path}
+}
+
+func (f *FileSessionStore) Put(ctx context.Context, s *StoredSession) error {
+	if s == nil || s.ID == "" {
+		return ErrInvalidSessionID
+	}
+	return f.update(ctx, func(ss []*StoredSession) []*StoredSession {
+		cp := *s
+		for i, old := range ss {
+			if old.ID == s.ID {
+				ss[i] = &cp
+				return ss
+			}
+		}
+		return append(ss, &cp)
+	})
+}
+
+func (f *FileSessionStore) Get(ctx context.Context, id string) (*StoredSession, error) {
+	ss, err := f.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+func (f *FileSessionStore) List(context.Context) ([]*StoredSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.read()
+}
+
+func (f *FileSessionStore) Delete(ctx context.Context, id string) error {
+	return f.update(ctx, func(ss []*StoredSession) []*StoredSession {
+		kept := ss[:0]
+		for _, s := range ss {
+			if s.ID != id {
+				kept = append(kept, s)
+			}
+		}
+		return kept
+	})
+}
+
+// update applies fn to the stored sessions while holding both the in-process & the file lock.
+func (f *FileSessionStore) update(ctx context.Context, fn func([]*StoredSession) []*StoredSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	unlock, err := lockFile(ctx, f.path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ss, err := f.read()
+	if err != nil {
+		return err
+	}
+	return f.write(fn(ss))
+}
+
+func (f *FileSessionStore) read() ([]*StoredSession, error) {
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("neo: failed to read the session store: %w", err)
+	}
+	ss := make([]*StoredSession, 0, 8)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 4096), len(b)+1)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		s := &StoredSession{}
+		if err := json.Unmarshal(line, s); err != nil {
+			return nil, fmt.Errorf("neo: failed to decode a stored session: %w", err)
+		}
+		ss = append(ss, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("neo: failed to read the session store: %w", err)
+	}
+	sortSessions(ss)
+	return ss, nil
+}
+
+// write replaces the file by renaming a fully written temporary file over it.
+func (f *FileSessionStore) write(ss []*StoredSession) error {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, s := range ss {
+		if err := enc.Encode(s); err != nil {
+			return fmt.Errorf("neo: failed to encode a stored session: %w", err)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("neo: failed to create a temporary session store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("neo: failed to write the session store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("neo: failed to sync the session store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("neo: failed to close the session store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("neo: failed to replace the session store: %w", err)
+	}
+	return nil
+}
+
+// lockFile acquires an exclusive lock on the given file, waiting until it's released.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	for {
+		unlock, ok, err := tryLockFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("neo: failed to lock the session store: %w", err)
+		}
+		if ok {
+			return unlock, nil
+		}
+		t := time.NewTimer(10 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, fmt.Errorf("neo: failed to lock the session store: %w", ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+func sortSessions(ss []*StoredSession) {
+	sort.Slice(ss, func(i, j int) bool {
+		if !ss[i].CreatedAt.Equal(ss[j].CreatedAt) {
+			return ss[i].CreatedAt.Before(ss[j].CreatedAt)
+		}
+		return ss[i].ID < ss[j].ID
+	})
+}
@@ -0,0 +1,22 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package neo
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile locks the given file by creating it, as the platform has no file locks.
+// A lock file left by a crashed process is never removed, since telling it apart from a live one is racy.
+func tryLockFile(path string) (func(), bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	_ = f.Close()
+	return func() { _ = os.Remove(path) }, true, nil
+}
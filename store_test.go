@@ -0,0 +1,111 @@
+package neo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enfunc/neo"
+)
+
+func TestSessionStores(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "sessions.jsonl")
+	for name, store := range map[string]neo.SessionStore{
+		"memory": neo.NewMemorySessionStore(),
+		"file":   neo.NewFileSessionStore(path),
+	} {
+		if err := checkSessionStore(ctx, store); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	// A second store on the same file sees what the first one wrote.
+	ss, err := neo.NewFileSessionStore(path).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 1 || ss[0].ID != "snd" {
+		t.Fatalf("TestSessionStores: unexpected sessions %v", ss)
+	}
+}
+
+func checkSessionStore(ctx context.Context, store neo.SessionStore) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	fst := &neo.StoredSession{ID: "fst", BankID: bankDNB, PSU: "psu", CreatedAt: now, Consent: neo.ConsentGranted}
+	snd := &neo.StoredSession{ID: "snd", BankID: bankSbanken, PSU: "psu", CreatedAt: now.Add(time.Second)}
+	for _, s := range []*neo.StoredSession{fst, snd, fst} {
+		if err := store.Put(ctx, s); err != nil {
+			return err
+		}
+	}
+	got, err := store.Get(ctx, "fst")
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got, fst) {
+		return errors.New("checkSessionStore: stored session differs")
+	}
+	ss, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ss) != 2 || ss[0].ID != "fst" || ss[1].ID != "snd" {
+		return errors.New("checkSessionStore: unexpected list")
+	}
+	if err := store.Delete(ctx, "fst"); err != nil {
+		return err
+	}
+	if _, err := store.Get(ctx, "fst"); !errors.Is(err, neo.ErrSessionNotFound) {
+		return errors.New("checkSessionStore: deleted session found")
+	}
+	return nil
+}
+
+func TestFileSessionStoreContention(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "sessions.jsonl")
+	// A lock file left by a crashed process doesn't block the store.
+	if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each store only shares the file lock with the others, as separate processes would.
+	const writers, writes = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store := neo.NewFileSessionStore(path)
+			for j := 0; j < writes; j++ {
+				if err := store.Put(ctx, &neo.StoredSession{ID: fmt.Sprintf("%d-%d", i, j), BankID: bankDNB}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	ss, err := neo.NewFileSessionStore(path).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != writers*writes {
+		t.Fatalf("TestFileSessionStoreContention: expected %d sessions, got %d", writers*writes, len(ss))
+	}
+}
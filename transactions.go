@@ -1,30 +1,180 @@
 package neo
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
+	"net/url"
+	"strings"
 )
 
 type Tx struct {
-	ID                   string     `json:"id"`
-	TransactionReference string     `json:"transactionReference"`
-	TransactionAmount    *Money     `json:"transactionAmount"`
-	CreditDebitIndicator string     `json:"creditDebitIndicator"`
-	BookingDate          *time.Time `json:"bookingDate"`
-	ValueDate            *time.Time `json:"valueDate"`
-	CounterpartyAccount  string     `json:"counterpartyAccount"`
-	CounterpartyName     string     `json:"counterpartyName"`
-	CounterpartyAgent    string     `json:"counterpartyAgent"`
+	ID                   string      `json:"id"`
+	TransactionReference string      `json:"transactionReference"`
+	TransactionAmount    *Money      `json:"transactionAmount"`
+	CreditDebitIndicator CreditDebit `json:"creditDebitIndicator"`
+	Status               TxStatus    `json:"status"`
+	BookingDate          *Date       `json:"bookingDate"`
+	ValueDate            *Date       `json:"valueDate"`
+	CounterpartyAccount  string      `json:"counterpartyAccount"`
+	CounterpartyName     string      `json:"counterpartyName"`
+	CounterpartyAgent    string      `json:"counterpartyAgent"`
+
+	// The details below are usually only reported by TxByID.
+
+	RemittanceInfoUnstructured string                    `json:"remittanceInformationUnstructured,omitempty"`
+	RemittanceInfoStructured   *RemittanceInfoStructured `json:"remittanceInformationStructured,omitempty"`
+	EndToEndIdentification     string                    `json:"endToEndIdentification,omitempty"`
+	BankTransactionCode        string                    `json:"bankTransactionCode,omitempty"` // E.g. the ISO 20022 code PMNT-RCDT-ESCT.
+	ExchangeRate               *ExchangeRate             `json:"currencyExchange,omitempty"`
+	CreditorName               string                    `json:"creditorName,omitempty"`
+	CreditorAccount            *AccountInfo              `json:"creditorAccount,omitempty"`
+	DebtorName                 string                    `json:"debtorName,omitempty"`
+	DebtorAccount              *AccountInfo              `json:"debtorAccount,omitempty"`
+	MerchantCategoryCode       string                    `json:"merchantCategoryCode,omitempty"` // The ISO 18245 code of card payments.
+}
+
+// ExchangeRate is the currency exchange applied to a transaction in a foreign currency.
+type ExchangeRate struct {
+	SourceCurrency Currency `json:"sourceCurrency"`
+	TargetCurrency Currency `json:"targetCurrency"`
+	UnitCurrency   Currency `json:"unitCurrency,omitempty"`
+	Rate           Amount   `json:"exchangeRate"` // The exact rate, i.e. the units of the target currency per unit currency.
+	QuotationDate  *Date    `json:"quotationDate,omitempty"`
+}
+
+// UnmarshalJSON decodes the transaction. If the bank doesn't report the status,
+// transactions without a booking date are considered pending.
+func (tx *Tx) UnmarshalJSON(data []byte) error {
+	type rawTx Tx
+	if err := json.Unmarshal(data, (*rawTx)(tx)); err != nil {
+		return fmt.Errorf("unable to unmarshal transaction: %w", err)
+	}
+	if tx.Status == "" {
+		tx.Status = TxBooked
+		if tx.BookingDate == nil || tx.BookingDate.IsZero() {
+			tx.Status = TxPending
+		}
+	}
+	return nil
+}
+
+// Localize converts the dates of the transaction to the time zone of the given ISO 3166 country,
+// which should be the country of the bank. See Date.Localize.
+func (tx *Tx) Localize(country string) {
+	for _, d := range []*Date{tx.BookingDate, tx.ValueDate} {
+		if d != nil {
+			*d = d.Localize(country)
+		}
+	}
+}
+
+// SignedAmount returns the amount of the transaction, negative for debits and positive for credits.
+// If the indicator is missing, the amount is returned as reported by the bank.
+func (tx *Tx) SignedAmount() Amount {
+	if tx.TransactionAmount == nil {
+		return Amount{}
+	}
+	v := tx.TransactionAmount.Value
+	switch tx.CreditDebitIndicator {
+	case Credit:
+		return v.Abs()
+	case Debit:
+		return v.Abs().Neg()
+	}
+	return v
+}
+
+// TxStatus is the booking status of a transaction.
+type TxStatus string
+
+const (
+	TxBooked  TxStatus = "booked"
+	TxPending TxStatus = "pending" // E.g. a card hold, which may still change or disappear.
+)
+
+// UnmarshalText accepts the ISO 20022 codes (BOOK, PDNG) and the spelled out statuses in any case.
+func (s *TxStatus) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(strings.TrimSpace(string(text))) {
+	case "BOOK", "BOOKED":
+		*s = TxBooked
+	case "PDNG", "PENDING":
+		*s = TxPending
+	default:
+		*s = TxStatus(strings.ToLower(strings.TrimSpace(string(text))))
+	}
+	return nil
+}
+
+// CreditDebit tells whether a transaction is a credit or a debit to the account.
+type CreditDebit string
+
+const (
+	Credit CreditDebit = "CRDT"
+	Debit  CreditDebit = "DBIT"
+)
+
+// UnmarshalText accepts the ISO 20022 codes (CRDT, DBIT), as well as the common variants
+// such as "credit", "CR" or "D", in any case.
+func (cd *CreditDebit) UnmarshalText(text []byte) error {
+	switch v := strings.ToUpper(strings.TrimSpace(string(text))); v {
+	case "CRDT", "CREDIT", "CR", "C", "+":
+		*cd = Credit
+	case "DBIT", "DEBIT", "DR", "D", "-":
+		*cd = Debit
+	default:
+		*cd = CreditDebit(v)
+	}
+	return nil
 }
 
 type Money struct {
-	Currency string `json:"currency"`
-	Value    string `json:"value"`
+	Currency Currency `json:"currency"`
+	Value    Amount   `json:"value"`
 }
 
-// Txs returns a list of transactions for the given account.
+// FromDate limits the transactions to the ones booked on or after the given date.
+func FromDate(d Date) Optional {
+	return query("fromDate", d.String())
+}
+
+// ToDate limits the transactions to the ones booked on or before the given date.
+func ToDate(d Date) Optional {
+	return query("toDate", d.String())
+}
+
+func query(key, value string) Optional {
+	return func(r *http.Request) {
+		q := r.URL.Query()
+		q.Set(key, value)
+		r.URL.RawQuery = q.Encode()
+	}
+}
+
+// BookingStatus limits the transactions to the ones with the given status. Banks which don't support
+// the filter return all the transactions, which are then filtered by the library.
+func BookingStatus(s TxStatus) Optional {
+	return query(queryBookingStatus, string(s))
+}
+
+const queryBookingStatus = "bookingStatus"
+
+// continuationKey requests the page following the one which returned the given key.
+func continuationKey(key string) Optional {
+	return func(r *http.Request) {
+		r.Header.Set(HeaderContinuationKey, key)
+	}
+}
+
+// Txs returns a list of transactions for the given account, following the pagination of the response, if any.
+// Use FromDate & ToDate to limit the date range.
+//
+// If a page other than the first one can't be retrieved, the transactions of the previous pages
+// are returned along with a *PartialError. If the bank requires an SCA in the middle of the range,
+// the transactions of the previous pages are returned along with the SCAHandler,
+// whose Retry resumes from the page that required it, storing all the transactions into a *[]*Tx.
 func (a *API) Txs(ctx context.Context, sessionID, accountID string, opts ...Optional) ([]*Tx, *SCAHandler, error) {
 	if sessionID == "" {
 		return nil, nil, ErrInvalidSessionID
@@ -33,9 +183,293 @@ func (a *API) Txs(ctx context.Context, sessionID, accountID string, opts ...Opti
 		return nil, nil, ErrInvalidAccountID
 	}
 	opts = append(opts, SessionID(sessionID))
-	uri := fmt.Sprintf("/ics/v3/accounts/%s/transactions", accountID)
+	p := &txPager{
+		api:  a,
+		uri:  fmt.Sprintf("/ics/v3/accounts/%s/transactions", accountID),
+		opts: opts,
+		seen: map[string]bool{},
+	}
+	return p.fetch(ctx, p.api.request(ctx, http.MethodGet, p.uri, nil, opts...))
+}
+
+// TxByID returns the details of the given transaction.
+func (a *API) TxByID(ctx context.Context, sessionID, accountID, txID string, opts ...Optional) (*Tx, *SCAHandler, error) {
+	if sessionID == "" {
+		return nil, nil, ErrInvalidSessionID
+	}
+	if accountID == "" {
+		return nil, nil, ErrInvalidAccountID
+	}
+	if txID == "" {
+		return nil, nil, ErrInvalidTxID
+	}
+	opts = append(opts, SessionID(sessionID))
+	uri := fmt.Sprintf("/ics/v3/accounts/%s/transactions/%s", accountID, txID)
 	req := a.request(ctx, http.MethodGet, uri, nil, opts...)
-	txs := make([]*Tx, 0, 32)
-	sca, err := a.do(req, http.StatusOK, &txs)
-	return txs, sca, err
+	tx := &Tx{}
+	sca, err := a.do(req, http.StatusOK, tx)
+	return tx, sca, err
+}
+
+// txPage is a page of transactions, which is either a JSON array or an object with a link to the next page.
+type txPage struct {
+	Txs  []*Tx
+	Next string // The URL of the next page.
+	Key  string // The continuation key of the next page.
+}
+
+func (p *txPage) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &p.Txs); err != nil {
+			return fmt.Errorf("unable to unmarshal transactions: %w", err)
+		}
+		return nil
+	}
+	aux := &struct {
+		Transactions    []*Tx   `json:"transactions"`
+		Links           []*Link `json:"links"`
+		ContinuationKey string  `json:"continuationKey"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return fmt.Errorf("unable to unmarshal transactions: %w", err)
+	}
+	p.Txs, p.Key = aux.Transactions, aux.ContinuationKey
+	for _, l := range aux.Links {
+		if l != nil && l.Rel == LinkRelNext {
+			p.Next = l.Href
+		}
+	}
+	return nil
+}
+
+// receiveHeader reads the pagination headers, unless the body already specified the next page.
+func (p *txPage) receiveHeader(h http.Header) {
+	if p.Next != "" || p.Key != "" {
+		return
+	}
+	p.Next, p.Key = nextLink(h.Get(HeaderLink)), h.Get(HeaderContinuationKey)
+}
+
+// txPager collects the transactions across pages.
+type txPager struct {
+	api   *API
+	uri   string
+	opts  []Optional
+	txs   []*Tx
+	pages int
+	seen  map[string]bool // The pages already requested, guarding against pagination loops.
+}
+
+func (p *txPager) fetch(ctx context.Context, req *http.Request) ([]*Tx, *SCAHandler, error) {
+	for req != nil {
+		txs, next, sca, err := p.fetchPage(ctx, req)
+		p.txs = append(p.txs, txs...)
+		if err != nil {
+			return p.txs, nil, err
+		}
+		if sca != nil {
+			return p.txs, p.resumable(sca, req), nil
+		}
+		req = next
+	}
+	if p.txs == nil {
+		p.txs = []*Tx{}
+	}
+	return p.txs, nil, nil
+}
+
+// fetchPage retrieves the page of the given request, returning the request for the following page, if any.
+func (p *txPager) fetchPage(ctx context.Context, req *http.Request) ([]*Tx, *http.Request, *SCAHandler, error) {
+	page := &txPage{}
+	sca, err := p.api.do(req, http.StatusOK, page)
+	if err != nil || sca != nil {
+		return nil, nil, sca, p.partial(err)
+	}
+	p.pages++
+	next, err := p.next(ctx, page)
+	return filterTxs(page.Txs, TxStatus(req.URL.Query().Get(queryBookingStatus))), next, nil, p.partial(err)
+}
+
+// next returns the request for the page following the given one, or nil if it's the last one.
+func (p *txPager) next(ctx context.Context, page *txPage) (*http.Request, error) {
+	var key string
+	switch {
+	case page.Next != "":
+		key = "next:" + page.Next
+	case page.Key != "":
+		key = "key:" + page.Key
+	default:
+		return nil, nil
+	}
+	if p.seen[key] {
+		return nil, fmt.Errorf("neo: %w: page %q was already retrieved", ErrInvalidPagination, key)
+	}
+	p.seen[key] = true
+	if page.Next != "" {
+		u, err := p.resolve(page.Next)
+		if err != nil {
+			return nil, err
+		}
+		return p.api.newRequest(ctx, http.MethodGet, u, nil, p.opts...)
+	}
+	opts := append(append([]Optional{}, p.opts...), continuationKey(page.Key))
+	return p.api.request(ctx, http.MethodGet, p.uri, nil, opts...), nil
+}
+
+// resolve resolves the link to the next page against the base URL of the client, rejecting links to
+// any other host, since the request carries the access token & the end-user headers.
+func (p *txPager) resolve(link string) (string, error) {
+	base, err := url.Parse(p.api.Client.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("neo: invalid base URL: %w", err)
+	}
+	u, err := base.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("neo: %w: invalid next page %q", ErrInvalidPagination, link)
+	}
+	if u.Scheme != base.Scheme || !strings.EqualFold(u.Host, base.Host) {
+		return "", fmt.Errorf("neo: %w: next page %q is not on %s", ErrInvalidPagination, link, base.Host)
+	}
+	return u.String(), nil
+}
+
+// filterTxs returns the transactions with the given status, or all of them if status is empty.
+func filterTxs(txs []*Tx, status TxStatus) []*Tx {
+	if status == "" {
+		return txs
+	}
+	filtered := txs[:0]
+	for _, tx := range txs {
+		if tx != nil && tx.Status == status {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
+}
+
+// partial wraps an error occurring after the first page into a *PartialError.
+func (p *txPager) partial(err error) error {
+	if err == nil || p.pages == 0 {
+		return err
+	}
+	return &PartialError{Pages: p.pages, Err: err}
+}
+
+// resumable makes the SCA resume the pagination from the given request.
+func (p *txPager) resumable(sca *SCAHandler, req *http.Request) *SCAHandler {
+	sca.Retry = func(ctx context.Context, v interface{}) (*SCAHandler, error) {
+		txs, h, err := p.fetch(ctx, req.Clone(ctx))
+		if u, ok := v.(*[]*Tx); ok {
+			*u = txs
+		}
+		return h, err
+	}
+	return sca
+}
+
+// TxIterator streams the transactions of an account page by page. See API.TxIterator.
+type TxIterator struct {
+	ctx   context.Context //nolint:containedctx
+	pager *txPager
+	req   *http.Request // The request for the next page, or nil if there are no more pages.
+	page  []*Tx
+	tx    *Tx
+	sca   *SCAHandler
+	err   error
+}
+
+// TxIterator returns an iterator over the transactions of the given account, which retrieves
+// the pages one at a time as they're iterated, accepting the same options as Txs:
+//
+//	it := api.TxIterator(ctx, sessionID, accountID)
+//	for it.Next() {
+//		tx := it.Tx()
+//		...
+//	}
+//	if sca := it.SCA(); sca != nil {
+//		// Let the end-user complete the SCA, then call it.Next() to resume.
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (a *API) TxIterator(ctx context.Context, sessionID, accountID string, opts ...Optional) *TxIterator {
+	it := &TxIterator{ctx: ctx}
+	switch {
+	case sessionID == "":
+		it.err = ErrInvalidSessionID
+	case accountID == "":
+		it.err = ErrInvalidAccountID
+	default:
+		opts = append(opts, SessionID(sessionID))
+		it.pager = &txPager{
+			api:  a,
+			uri:  fmt.Sprintf("/ics/v3/accounts/%s/transactions", accountID),
+			opts: opts,
+			seen: map[string]bool{},
+		}
+		it.req = a.request(ctx, http.MethodGet, it.pager.uri, nil, opts...)
+	}
+	return it
+}
+
+// Next advances the iterator to the next transaction, retrieving the next page if needed.
+// It returns false once there are no more transactions, an error occurs, or an SCA is required.
+// In the latter case, calling Next once the SCA has been completed resumes the iteration.
+func (it *TxIterator) Next() bool {
+	it.tx = nil
+	for len(it.page) == 0 {
+		if it.err != nil || it.req == nil {
+			return false
+		}
+		if it.sca != nil {
+			it.sca = nil
+			it.req = it.req.Clone(it.ctx)
+		}
+		if it.fetch(it.ctx) != nil {
+			return false
+		}
+	}
+	it.tx, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// fetch retrieves the next page, returning the SCA handler if the bank requires one.
+func (it *TxIterator) fetch(ctx context.Context) *SCAHandler {
+	txs, next, sca, err := it.pager.fetchPage(ctx, it.req)
+	it.page, it.err = txs, err
+	if sca != nil {
+		it.sca = it.resumable(sca)
+		return it.sca
+	}
+	it.req = next
+	return nil
+}
+
+// resumable makes the SCA retrieve the page which required it,
+// so that it can be polled, after which Next continues with the page.
+func (it *TxIterator) resumable(sca *SCAHandler) *SCAHandler {
+	sca.Retry = func(ctx context.Context, _ interface{}) (*SCAHandler, error) {
+		it.sca = nil
+		it.req = it.req.Clone(ctx)
+		h := it.fetch(ctx)
+		return h, it.err
+	}
+	return sca
+}
+
+// Tx returns the current transaction.
+func (it *TxIterator) Tx() *Tx {
+	return it.tx
+}
+
+// SCA returns the SCA handler if the iteration stopped because the bank requires an SCA.
+func (it *TxIterator) SCA() *SCAHandler {
+	return it.sca
+}
+
+// Err returns the error which stopped the iteration, if any. Errors occurring
+// after the first page are wrapped into a *PartialError.
+func (it *TxIterator) Err() error {
+	return it.err
 }
@@ -2,7 +2,13 @@ package neo_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
 
 	"github.com/enfunc/neo"
 )
@@ -25,3 +31,197 @@ func checkSandboxTxs(ctx context.Context, api *neo.API, s *neo.Session, acc []*n
 	}
 	return nil
 }
+
+func TestTxsPagination(t *testing.T) {
+	consented := false
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		if q := r.URL.Query(); q.Get("fromDate") != "2022-01-01" || q.Get("toDate") != "2022-12-31" {
+			t.Errorf("TestTxsPagination: missing date range in %s", r.URL)
+		}
+		switch {
+		case r.URL.Query().Get("page") == "2":
+			return response(http.StatusOK, `{"transactions":[{"id":"2"}],"continuationKey":"k3"}`, nil), nil
+		case r.Header.Get(neo.HeaderContinuationKey) == "k3" && !consented:
+			return response(510, `{"type":"CONSENT","errorCode":"1426","links":[{"href":"https://bank.no/consent"}]}`, nil), nil
+		case r.Header.Get(neo.HeaderContinuationKey) == "k3":
+			return response(http.StatusOK, `[{"id":"3"}]`, nil), nil
+		}
+		h := http.Header{}
+		h.Set(neo.HeaderLink, `<https://neo.test/ics/v3/accounts/acc/transactions?page=2>; rel="next"`)
+		return response(http.StatusOK, `[{"id":"1"}]`, h), nil
+	})
+	api := fakeAPI(d)
+	ctx := context.TODO()
+	from, to := neo.NewDate(2022, time.January, 1), neo.MustParseDate("2022-12-31")
+
+	txs, sca, err := api.Txs(ctx, "session", "acc", neo.FromDate(from), neo.ToDate(to))
+	if err != nil || sca == nil || len(txs) != 2 {
+		t.Fatalf("TestTxsPagination: expected an SCA after 2 txs, got %d txs, %v, %v", len(txs), sca, err)
+	}
+	consented = true
+	if _, err := sca.Retry(ctx, &txs); err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 3 || txs[2].ID != "3" {
+		t.Fatalf("TestTxsPagination: expected 3 txs after resuming, got %d", len(txs))
+	}
+
+	d = doerFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get(neo.HeaderContinuationKey) != "" {
+			return response(http.StatusBadGateway, `{}`, nil), nil
+		}
+		return response(http.StatusOK, `{"transactions":[{"id":"1"}],"continuationKey":"k2"}`, nil), nil
+	})
+	txs, _, err = fakeAPI(d).Txs(ctx, "session", "acc")
+	var partial *neo.PartialError
+	if !errors.As(err, &partial) || partial.Pages != 1 || len(txs) != 1 {
+		t.Fatalf("TestTxsPagination: expected a partial result, got %d txs, %v", len(txs), err)
+	}
+}
+
+func TestTxsPaginationLinks(t *testing.T) {
+	for link, valid := range map[string]bool{
+		"/ics/v3/accounts/acc/transactions?page=2":                    true,
+		"https://NEO.test/ics/v3/accounts/acc/transactions?page=2":    true,
+		"https://evil.test/ics/v3/accounts/acc/transactions?page=2":   false,
+		"http://neo.test/ics/v3/accounts/acc/transactions?page=2":     false,
+		"//evil.test/ics/v3/accounts/acc/transactions?page=2":         false,
+		"https://neo.test@evil.test/ics/v3/accounts/acc/transactions": false,
+		"http://[::1%41/transactions":                                 false,
+		"https://neo.test/%zz":                                        false,
+	} {
+		var hosts []string
+		d := doerFunc(func(r *http.Request) (*http.Response, error) {
+			hosts = append(hosts, r.URL.Host)
+			if r.URL.Query().Get("page") == "2" {
+				return response(http.StatusOK, `[{"id":"2"}]`, nil), nil
+			}
+			return response(http.StatusOK, fmt.Sprintf(`{"transactions":[{"id":"1"}],"links":[{"rel":"next","href":%q}]}`, link), nil), nil
+		})
+		txs, _, err := fakeAPI(d).Txs(context.TODO(), "session", "acc")
+		if valid && (err != nil || len(txs) != 2) {
+			t.Fatalf("TestTxsPaginationLinks: %s: expected 2 txs, got %d, %v", link, len(txs), err)
+		}
+		if !valid && (!errors.Is(err, neo.ErrInvalidPagination) || len(txs) != 1) {
+			t.Fatalf("TestTxsPaginationLinks: %s: expected ErrInvalidPagination, got %d txs, %v", link, len(txs), err)
+		}
+		for _, h := range hosts {
+			if !strings.EqualFold(h, "neo.test") {
+				t.Fatalf("TestTxsPaginationLinks: %s: the token was sent to %s", link, h)
+			}
+		}
+	}
+}
+
+func TestTxIterator(t *testing.T) {
+	consented := false
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.Header.Get(neo.HeaderContinuationKey) {
+		case "":
+			return response(http.StatusOK, `{"transactions":[{"id":"1"},{"id":"2"}],"continuationKey":"k2"}`, nil), nil
+		case "k2":
+			if !consented {
+				return response(510, `{"type":"CONSENT","errorCode":"1426","links":[{"href":"https://bank.no/consent"}]}`, nil), nil
+			}
+			return response(http.StatusOK, `{"transactions":[],"continuationKey":"k3"}`, nil), nil
+		}
+		return response(http.StatusOK, `[{"id":"3"}]`, nil), nil
+	})
+	it := fakeAPI(d).TxIterator(context.TODO(), "session", "acc")
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Tx().ID)
+	}
+	if it.SCA() == nil || it.Err() != nil || len(ids) != 2 {
+		t.Fatalf("TestTxIterator: expected an SCA after 2 txs, got %v, %v", ids, it.Err())
+	}
+	consented = true
+	for it.Next() {
+		ids = append(ids, it.Tx().ID)
+	}
+	if it.SCA() != nil || it.Err() != nil || strings.Join(ids, ",") != "1,2,3" {
+		t.Fatalf("TestTxIterator: expected to resume after the SCA, got %v, %v", ids, it.Err())
+	}
+}
+
+func TestTxStatus(t *testing.T) {
+	var txs []*neo.Tx
+	if err := json.Unmarshal([]byte(`[
+		{"id":"1","transactionAmount":{"currency":"NOK","value":"10.00"},"creditDebitIndicator":"credit","bookingDate":"2022-03-01T00:00:00Z"},
+		{"id":"2","transactionAmount":{"currency":"NOK","value":"-7.50"},"creditDebitIndicator":"DBIT","status":"PDNG"},
+		{"id":"3","transactionAmount":{"currency":"NOK","value":"7.50"},"creditDebitIndicator":"d"},
+		{"id":"4","transactionAmount":{"currency":"NOK","value":"-1.00"}}
+	]`), &txs); err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		status neo.TxStatus
+		cd     neo.CreditDebit
+		signed string
+	}{
+		{neo.TxBooked, neo.Credit, "10.00"},
+		{neo.TxPending, neo.Debit, "-7.50"},
+		{neo.TxPending, neo.Debit, "-7.50"},
+		{neo.TxPending, "", "-1.00"},
+	}
+	for i, e := range expected {
+		tx := txs[i]
+		if tx.Status != e.status || tx.CreditDebitIndicator != e.cd || tx.SignedAmount().String() != e.signed {
+			t.Errorf("TestTxStatus: tx %s: got %s %s %s", tx.ID, tx.Status, tx.CreditDebitIndicator, tx.SignedAmount())
+		}
+	}
+
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Query().Get("bookingStatus") != "booked" {
+			t.Errorf("TestTxStatus: missing the status filter in %s", r.URL)
+		}
+		// The bank ignores the filter.
+		return response(http.StatusOK, `[{"id":"1","bookingDate":"2022-03-01T00:00:00Z"},{"id":"2"}]`, nil), nil
+	})
+	booked, _, err := fakeAPI(d).Txs(context.TODO(), "session", "acc", neo.BookingStatus(neo.TxBooked))
+	if err != nil || len(booked) != 1 || booked[0].ID != "1" {
+		t.Fatalf("TestTxStatus: expected only the booked tx, got %d, %v", len(booked), err)
+	}
+}
+
+func TestTxByID(t *testing.T) {
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/ics/v3/accounts/acc/transactions/tx-1" {
+			t.Errorf("TestTxByID: unexpected path %s", r.URL.Path)
+		}
+		return response(http.StatusOK, `{
+			"id": "tx-1",
+			"transactionAmount": {"currency": "NOK", "value": "-105.20"},
+			"creditDebitIndicator": "DBIT",
+			"bookingDate": "2022-03-01",
+			"remittanceInformationStructured": {"reference": "RF18539007547034", "referenceType": "SCOR"},
+			"endToEndIdentification": "e2e-1",
+			"bankTransactionCode": "PMNT-CCRD-POSD",
+			"currencyExchange": {"sourceCurrency": "EUR", "targetCurrency": "NOK", "exchangeRate": "10.52"},
+			"creditorName": "Kaffebar AS",
+			"creditorAccount": {"iban": "NO9386011117947"},
+			"merchantCategoryCode": "5814"
+		}`, nil), nil
+	})
+	tx, _, err := fakeAPI(d).TxByID(context.TODO(), "session", "acc", "tx-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.RemittanceInfoStructured == nil || tx.RemittanceInfoStructured.Reference != "RF18539007547034" ||
+		tx.EndToEndIdentification != "e2e-1" || tx.BankTransactionCode != "PMNT-CCRD-POSD" ||
+		tx.ExchangeRate == nil || tx.ExchangeRate.Rate.String() != "10.52" ||
+		tx.CreditorAccount == nil || tx.CreditorAccount.IBAN != "NO9386011117947" || tx.MerchantCategoryCode != "5814" {
+		t.Fatalf("TestTxByID: unexpected details %+v", tx)
+	}
+	// The details don't change the identity of the transaction.
+	listed := &neo.Tx{}
+	if err := json.Unmarshal([]byte(`{"id":"tx-1","transactionAmount":{"currency":"NOK","value":"-105.2"},"creditDebitIndicator":"DBIT","bookingDate":"2022-03-01"}`), listed); err != nil {
+		t.Fatal(err)
+	}
+	if listed.Hash() != tx.Hash() {
+		t.Fatal("TestTxByID: the details should not change the hash")
+	}
+	if _, _, err := fakeAPI(d).TxByID(context.TODO(), "session", "acc", ""); !errors.Is(err, neo.ErrInvalidTxID) {
+		t.Fatalf("TestTxByID: expected ErrInvalidTxID, got %v", err)
+	}
+}
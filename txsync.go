@@ -0,0 +1,297 @@
+package neo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTxOverlap is how far back before the watermark a TxSyncer requests the transactions,
+// catching the ones booked late or with a backdated booking date.
+const DefaultTxOverlap = 7 * 24 * time.Hour
+
+// Watermark records how far the transactions of an account have been synced.
+type Watermark struct {
+	AccountID     string            `json:"accountId"`
+	BookedThrough Date              `json:"bookedThrough"` // The latest booking date seen.
+	Seen          map[string]TxMark `json:"seen"`          // The transactions within the overlap window, by key.
+	SyncedAt      time.Time         `json:"syncedAt"`
+}
+
+// TxMark is what a watermark remembers about a transaction.
+type TxMark struct {
+	Hash string `json:"hash"` // See Tx.Hash.
+	Date Date   `json:"date"` // The booking date, if booked.
+}
+
+// WatermarkStore persists the watermarks of a TxSyncer.
+type WatermarkStore interface {
+	// Get returns the watermark of the given account, or ErrWatermarkNotFound.
+	Get(ctx context.Context, accountID string) (*Watermark, error)
+	// Put creates or replaces the watermark of the account.
+	Put(ctx context.Context, w *Watermark) error
+}
+
+// MemoryWatermarkStore keeps the watermarks in memory.
+type MemoryWatermarkStore struct {
+	mu         sync.RWMutex
+	watermarks map[string]*Watermark
+}
+
+// NewMemoryWatermarkStore creates an empty in-memory watermark store.
+func NewMemoryWatermarkStore() *MemoryWatermarkStore {
+	return &MemoryWatermarkStore{
+		watermarks: make(map[string]*Watermark),
+	}
+}
+
+func (m *MemoryWatermarkStore) Get(_ context.Context, accountID string) (*Watermark, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	w, ok := m.watermarks[accountID]
+	if !ok {
+		return nil, ErrWatermarkNotFound
+	}
+	return w.clone(), nil
+}
+
+func (m *MemoryWatermarkStore) Put(_ context.Context, w *Watermark) error {
+	if w == nil || w.AccountID == "" {
+		return ErrInvalidAccountID
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watermarks[w.AccountID] = w.clone()
+	return nil
+}
+
+func (w *Watermark) clone() *Watermark {
+	c := *w
+	c.Seen = make(map[string]TxMark, len(w.Seen))
+	for k, v := range w.Seen {
+		c.Seen[k] = v
+	}
+	return &c
+}
+
+// TxChangeType describes how a transaction changed since the previous sync.
+type TxChangeType string
+
+const (
+	TxNew     TxChangeType = "new"
+	TxChanged TxChangeType = "changed"
+	TxRemoved TxChangeType = "removed" // E.g. a pending transaction replaced by the booked one.
+)
+
+// TxChange is a transaction which changed since the previous sync.
+type TxChange struct {
+	Type TxChangeType
+	Key  string // The ID of the transaction, or a key derived from its hash if it has none.
+	Tx   *Tx    // The transaction, unless it was removed.
+}
+
+// TxSink receives the changes of a sync. If it fails, the watermark isn't advanced,
+// so the same changes are delivered again on the next sync.
+type TxSink func(ctx context.Context, accountID string, changes []*TxChange) error
+
+// TxSyncer incrementally syncs the transactions of accounts, requesting only the transactions
+// since the last sync (minus an overlap window) and reporting what changed.
+//
+// The watermarks are stored by account ID, which is assigned per session. When the end-user
+// re-consents, move the watermarks over to the new IDs, see RemapAccountIDs.
+type TxSyncer struct {
+	API     *API
+	Store   WatermarkStore
+	Sink    TxSink        // Optional.
+	Overlap time.Duration // Defaults to DefaultTxOverlap.
+	Country string        // The ISO 3166 country of the bank, used to localize the dates. See Tx.Localize.
+}
+
+// NewTxSyncer creates a syncer keeping the watermarks in the given store, or in memory if nil.
+func NewTxSyncer(api *API, store WatermarkStore, sink TxSink) *TxSyncer {
+	if store == nil {
+		store = NewMemoryWatermarkStore()
+	}
+	return &TxSyncer{
+		API:     api,
+		Store:   store,
+		Sink:    sink,
+		Overlap: DefaultTxOverlap,
+	}
+}
+
+// Sync retrieves the transactions of the account since its watermark, passes the changes to the sink,
+// and advances the watermark. If the bank requires an SCA, its Retry resumes the sync,
+// storing the changes into a *[]*TxChange. If only some of the transactions could be retrieved,
+// a *PartialError is returned and the watermark is left untouched.
+func (s *TxSyncer) Sync(ctx context.Context, sessionID, accountID string, opts ...Optional) ([]*TxChange, *SCAHandler, error) {
+	w, err := s.Store.Get(ctx, accountID)
+	switch {
+	case errors.Is(err, ErrWatermarkNotFound):
+		w = &Watermark{AccountID: accountID, Seen: map[string]TxMark{}}
+	case err != nil:
+		return nil, nil, fmt.Errorf("neo: failed to retrieve the watermark: %w", err)
+	}
+	from := s.windowStart(w.BookedThrough)
+	if !from.IsZero() {
+		opts = append(opts, FromDate(from))
+	}
+	txs, sca, err := s.API.Txs(ctx, sessionID, accountID, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sca != nil {
+		return nil, s.resumable(sca, w, from), nil
+	}
+	changes, err := s.apply(ctx, w, from, txs)
+	return changes, nil, err
+}
+
+// resumable makes the SCA resume the sync once the remaining transactions are retrieved.
+func (s *TxSyncer) resumable(sca *SCAHandler, w *Watermark, from Date) *SCAHandler {
+	retry := sca.Retry
+	sca.Retry = func(ctx context.Context, v interface{}) (*SCAHandler, error) {
+		var txs []*Tx
+		h, err := retry(ctx, &txs)
+		if err != nil {
+			return nil, err
+		}
+		if h != nil {
+			return s.resumable(h, w, from), nil
+		}
+		changes, err := s.apply(ctx, w, from, txs)
+		if u, ok := v.(*[]*TxChange); ok {
+			*u = changes
+		}
+		return nil, err
+	}
+	return sca
+}
+
+// apply diffs the transactions of the window against the watermark, and advances it.
+func (s *TxSyncer) apply(ctx context.Context, w *Watermark, from Date, txs []*Tx) ([]*TxChange, error) {
+	var changes []*TxChange
+	seen := make(map[string]TxMark, len(txs))
+	bookedThrough := w.BookedThrough
+	for key, tx := range keyTxs(txs) {
+		if s.Country != "" {
+			tx.Localize(s.Country)
+		}
+		m := TxMark{Hash: tx.Hash()}
+		if tx.BookingDate != nil {
+			m.Date = *tx.BookingDate
+			if m.Date.After(bookedThrough) {
+				bookedThrough = m.Date
+			}
+		}
+		seen[key] = m
+		switch prev, ok := w.Seen[key]; {
+		case !ok:
+			changes = append(changes, &TxChange{Type: TxNew, Key: key, Tx: tx})
+		case prev.Hash != m.Hash:
+			changes = append(changes, &TxChange{Type: TxChanged, Key: key, Tx: tx})
+		}
+	}
+	for key, prev := range w.Seen {
+		if _, ok := seen[key]; !ok && (prev.Date.IsZero() || !prev.Date.Before(from)) {
+			changes = append(changes, &TxChange{Type: TxRemoved, Key: key})
+		}
+	}
+	sortTxChanges(changes)
+	if s.Sink != nil && len(changes) > 0 {
+		if err := s.Sink(ctx, w.AccountID, changes); err != nil {
+			return changes, fmt.Errorf("neo: TxSink: %w", err)
+		}
+	}
+	// Only the transactions within the next window are needed to detect changes.
+	next := &Watermark{AccountID: w.AccountID, BookedThrough: bookedThrough, Seen: seen, SyncedAt: time.Now()}
+	nextFrom := s.windowStart(bookedThrough)
+	for key, m := range seen {
+		if !m.Date.IsZero() && m.Date.Before(nextFrom) {
+			delete(seen, key)
+		}
+	}
+	if err := s.Store.Put(ctx, next); err != nil {
+		return changes, fmt.Errorf("neo: failed to store the watermark: %w", err)
+	}
+	return changes, nil
+}
+
+func (s *TxSyncer) windowStart(bookedThrough Date) Date {
+	if bookedThrough.IsZero() {
+		return Date{}
+	}
+	overlap := s.Overlap
+	if overlap <= 0 {
+		overlap = DefaultTxOverlap
+	}
+	const day = 24 * time.Hour
+	return bookedThrough.AddDays(-int((overlap + day - 1) / day))
+}
+
+// keyTxs keys the transactions by their IDs. Transactions without an ID are keyed by their hash
+// and their occurrence, so that identical transactions, e.g. two coffees bought on the same day, are kept apart.
+func keyTxs(txs []*Tx) map[string]*Tx {
+	keyed := make(map[string]*Tx, len(txs))
+	occurrences := make(map[string]int)
+	for _, tx := range txs {
+		if tx == nil {
+			continue
+		}
+		key := tx.ID
+		if key == "" {
+			h := tx.Hash()
+			occurrences[h]++
+			key = "hash:" + h + "#" + strconv.Itoa(occurrences[h])
+		}
+		keyed[key] = tx
+	}
+	return keyed
+}
+
+func sortTxChanges(changes []*TxChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+		return changes[i].Key < changes[j].Key
+	})
+}
+
+// Hash returns a hash of the canonical content of the transaction, ignoring its ID.
+// Transactions which only differ in formatting, e.g. "10.5" and "10.50", have the same hash.
+// The details usually only reported by TxByID are left out, so a transaction has the same hash
+// whichever endpoint it was retrieved from.
+func (tx *Tx) Hash() string {
+	var amount, currency string
+	if m := tx.TransactionAmount; m != nil {
+		amount, currency = m.Value.Round(m.Value.Decimals()).String(), strings.ToUpper(string(m.Currency))
+	}
+	date := func(d *Date) string {
+		if d == nil {
+			return ""
+		}
+		return d.String()
+	}
+	fields := []string{
+		strings.TrimSpace(tx.TransactionReference),
+		amount,
+		currency,
+		string(tx.CreditDebitIndicator),
+		string(tx.Status),
+		date(tx.BookingDate),
+		date(tx.ValueDate),
+		NormalizeBBAN(tx.CounterpartyAccount),
+		strings.Join(strings.Fields(strings.ToUpper(tx.CounterpartyName)), " "),
+		strings.TrimSpace(tx.CounterpartyAgent),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
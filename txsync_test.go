@@ -0,0 +1,65 @@
+package neo_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/enfunc/neo"
+)
+
+func TestTxSyncer(t *testing.T) {
+	body := `[
+		{"id":"a","transactionAmount":{"currency":"NOK","value":"100.00"},"bookingDate":"2022-03-01T00:00:00Z"},
+		{"id":"b","transactionAmount":{"currency":"NOK","value":"-20.00"},"bookingDate":"2022-03-10T00:00:00Z"},
+		{"transactionAmount":{"currency":"NOK","value":"-5.00"},"counterpartyName":"Kaffebar"}
+	]`
+	var query string
+	d := doerFunc(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.RawQuery
+		return response(http.StatusOK, body, nil), nil
+	})
+	var sunk []*neo.TxChange
+	s := neo.NewTxSyncer(fakeAPI(d), nil, func(_ context.Context, _ string, changes []*neo.TxChange) error {
+		sunk = append(sunk, changes...)
+		return nil
+	})
+	ctx := context.TODO()
+
+	changes, _, err := s.Sync(ctx, "session", "acc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || len(sunk) != 3 || query != "" {
+		t.Fatalf("TestTxSyncer: expected 3 new txs on the first sync, got %d (%q)", len(changes), query)
+	}
+
+	// The pending coffee got booked, and b got a new amount.
+	body = `[
+		{"id":"b","transactionAmount":{"currency":"NOK","value":"-25"},"bookingDate":"2022-03-10T00:00:00Z"},
+		{"transactionAmount":{"currency":"NOK","value":"-5.0"},"counterpartyName":"KAFFEBAR","bookingDate":"2022-03-11T00:00:00Z"}
+	]`
+	changes, _, err = s.Sync(ctx, "session", "acc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "fromDate=2022-03-03" {
+		t.Fatalf("TestTxSyncer: expected the overlap window to be requested, got %q", query)
+	}
+	var types []string
+	for _, c := range changes {
+		types = append(types, string(c.Type))
+	}
+	if strings.Join(types, ",") != "changed,new,removed" {
+		t.Fatalf("TestTxSyncer: unexpected changes %v", types)
+	}
+
+	changes, _, err = s.Sync(ctx, "session", "acc")
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("TestTxSyncer: expected no changes, got %d, %v", len(changes), err)
+	}
+	if w, err := s.Store.Get(ctx, "acc"); err != nil || len(w.Seen) != 2 || w.BookedThrough.Day() != 11 {
+		t.Fatalf("TestTxSyncer: unexpected watermark %+v, %v", w, err)
+	}
+}